@@ -0,0 +1,105 @@
+package mysql
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"reflect"
+)
+
+// BatchError error of CreateInBatches, Index is the failed row or the first row of the failed chunk
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Index, e.Err.Error())
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+func (db *DB) CreateInBatches(list interface{}, batchSize int, opts ...Option) (inserted *InsertResult, err error) {
+	opts, end := db.observe("CreateInBatches", OperationCreate, list, opts)
+	defer end(&err)
+	listV := reflect.ValueOf(list)
+	if listV.Kind() == reflect.Ptr {
+		listV = listV.Elem()
+	}
+	if listV.Kind() != reflect.Slice {
+		return nil, ErrorModel()
+	}
+	elem := listV.Type().Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return nil, ErrorModel()
+	}
+	if listV.Len() == 0 {
+		return &InsertResult{}, nil
+	}
+	if batchSize <= 0 {
+		batchSize = listV.Len()
+	}
+
+	model := reflect.New(elem).Interface()
+	query, queryOpt := db.QueryBuilder(model, opts...)
+	if !queryOpt.BatchTransaction || inTransaction(query) {
+		return db.createInBatches(model, listV, batchSize, query, queryOpt)
+	}
+	var result *InsertResult
+	err = db.Transaction(func(tx *gorm.DB) error {
+		query, _ := db.QueryBuilder(model, append(opts[:len(opts):len(opts)], db.WithDB(tx))...)
+		r, err := db.createInBatches(model, listV, batchSize, query, queryOpt)
+		result = r
+		return err
+	}, db.WithReadOnly(false))
+	if err != nil {
+		//everything is rolled back
+		return &InsertResult{}, err
+	}
+	return result, nil
+}
+
+func (db *DB) createInBatches(model interface{}, listV reflect.Value, batchSize int, query *gorm.DB, queryOpt *QueryOption) (*InsertResult, error) {
+	query = db.insertClauses(query, queryOpt)
+	result := &InsertResult{}
+	for i := 0; i < listV.Len(); i += batchSize {
+		end := i + batchSize
+		if end > listV.Len() {
+			end = listV.Len()
+		}
+		chunk := listV.Slice(i, end).Interface()
+		keys := db.unsetPKs(model, chunk, queryOpt)
+		tx := query.Session(&gorm.Session{}).Create(chunk)
+		err := tx.Error
+		if err == nil {
+			db.resetPKs(keys, end-i, tx.RowsAffected)
+			result.add(db.insertResult(queryOpt, end-i, tx.RowsAffected))
+			continue
+		}
+		if !db.IsUniqueIndexError(err) {
+			return result, &BatchError{Index: i, Err: ErrorQuery(err)}
+		}
+		//a failed statement is rolled back by itself, so find the duplicate row by inserting the chunk one by one
+		for j := i; j < end; j++ {
+			row := listV.Index(j)
+			if row.Kind() != reflect.Ptr {
+				row = row.Addr()
+			}
+			keys := db.unsetPKs(model, row.Interface(), queryOpt)
+			tx := query.Session(&gorm.Session{}).Create(row.Interface())
+			if err := tx.Error; err != nil {
+				if db.IsUniqueIndexError(err) {
+					return result, &BatchError{Index: j, Err: GetUniqueIndexError(model, err.Error())}
+				}
+				return result, &BatchError{Index: j, Err: ErrorQuery(err)}
+			}
+			db.resetPKs(keys, 1, tx.RowsAffected)
+			result.add(db.insertResult(queryOpt, 1, tx.RowsAffected))
+		}
+	}
+	return result, nil
+}
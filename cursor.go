@@ -0,0 +1,250 @@
+package mysql
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type Cursor struct {
+	After string
+	Size  int
+	Sort  []string
+}
+
+type CursorRes struct {
+	Next string `json:"next"`
+	Prev string `json:"prev"`
+}
+
+type cursorValue struct {
+	Values   []interface{} `json:"v"`
+	Backward bool          `json:"b,omitempty"`
+}
+
+type cursorColumn struct {
+	Name string
+	Desc bool
+}
+
+var cursorColumnExp = regexp.MustCompile(`^[A-Za-z0-9_.` + "`" + `]+$`)
+
+func encodeCursor(values []interface{}, backward bool) (string, error) {
+	for i, v := range values {
+		if valuer, ok := v.(driver.Valuer); ok {
+			dv, err := valuer.Value()
+			if err != nil {
+				return "", ErrorCursor(err)
+			}
+			v = dv
+		}
+		if t, ok := v.(time.Time); ok {
+			v = t.Format("2006-01-02 15:04:05.999999")
+		}
+		values[i] = v
+	}
+	b, err := json.Marshal(cursorValue{Values: values, Backward: backward})
+	if err != nil {
+		return "", ErrorCursor(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(cursor string) (*cursorValue, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrorCursor(err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var v cursorValue
+	if err := decoder.Decode(&v); err != nil {
+		return nil, ErrorCursor(err)
+	}
+	return &v, nil
+}
+
+func (db *DB) cursorColumns(model interface{}, sort []string) ([]cursorColumn, error) {
+	var columns []cursorColumn
+	for _, s := range sort {
+		fields := strings.Fields(s)
+		if len(fields) == 0 || len(fields) > 2 || !cursorColumnExp.MatchString(fields[0]) {
+			return nil, ErrorCursor("sort column " + s + " is invalid")
+		}
+		column := cursorColumn{Name: fields[0]}
+		if len(fields) == 2 {
+			switch strings.ToLower(fields[1]) {
+			case "asc":
+			case "desc":
+				column.Desc = true
+			default:
+				return nil, ErrorCursor("sort direction " + s + " is invalid")
+			}
+		}
+		columns = append(columns, column)
+	}
+
+	primaryKey := db.getPKName(model)
+	if primaryKey == "" {
+		return nil, ErrorPrimaryKeyUnset()
+	}
+	for _, column := range columns {
+		if cursorColumnName(column.Name) == primaryKey {
+			return columns, nil
+		}
+	}
+	desc := true
+	if len(columns) > 0 {
+		desc = columns[len(columns)-1].Desc
+	}
+	return append(columns, cursorColumn{Name: primaryKey, Desc: desc}), nil
+}
+
+func cursorColumnName(name string) string {
+	if i := strings.LastIndex(name, "."); i != -1 {
+		name = name[i+1:]
+	}
+	return strings.Trim(name, "`")
+}
+
+// cursorWhere build (c1 > v1) or (c1 = v1 and c2 > v2) ... respecting the direction of each column
+func cursorWhere(columns []cursorColumn, values []interface{}, backward bool) (string, []interface{}) {
+	var ors []string
+	var args []interface{}
+	for i, column := range columns {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, columns[j].Name+" = ?")
+			args = append(args, values[j])
+		}
+		if column.Desc != backward {
+			ands = append(ands, column.Name+" < ?")
+		} else {
+			ands = append(ands, column.Name+" > ?")
+		}
+		args = append(args, values[i])
+		ors = append(ors, "("+strings.Join(ands, " and ")+")")
+	}
+	return strings.Join(ors, " or "), args
+}
+
+func (db *DB) cursorValues(item reflect.Value, columns []cursorColumn) ([]interface{}, error) {
+	if item.Kind() == reflect.Ptr {
+		item = item.Elem()
+	}
+	itemT := item.Type()
+	values := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		name := cursorColumnName(column.Name)
+		found := false
+		for i := 0; i < itemT.NumField(); i++ {
+			if db.getColumnName(itemT.Field(i)) == name {
+				values = append(values, item.Field(i).Interface())
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, ErrorCursor("sort column " + column.Name + " is not a field of model")
+		}
+	}
+	return values, nil
+}
+
+func (db *DB) FindCursorPage(list interface{}, opts ...Option) (res *CursorRes, err error) {
+	opts, end := db.observe("FindCursorPage", OperationFind, list, opts)
+	defer end(&err)
+	listT := reflect.TypeOf(list)
+	if listT.Kind() != reflect.Ptr || listT.Elem().Kind() != reflect.Slice {
+		return nil, ErrorModel()
+	}
+	if !(listT.Elem().Elem().Kind() == reflect.Struct || (listT.Elem().Elem().Kind() == reflect.Ptr && listT.Elem().Elem().Elem().Kind() == reflect.Struct)) {
+		return nil, ErrorModel()
+	}
+
+	elem := listT.Elem().Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+
+	model := reflect.New(elem).Interface()
+	query, queryOpt := db.readQueryBuilder(model, opts...)
+	if err := db.checkLock(query, queryOpt); err != nil {
+		return nil, err
+	}
+	if queryOpt.Cursor == nil || queryOpt.Cursor.Size <= 0 {
+		return nil, ErrorCursor("cursor not supplied")
+	}
+
+	columns, err := db.cursorColumns(model, queryOpt.Cursor.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	var current *cursorValue
+	if queryOpt.Cursor.After != "" {
+		current, err = decodeCursor(queryOpt.Cursor.After)
+		if err != nil {
+			return nil, err
+		}
+		if len(current.Values) != len(columns) {
+			return nil, ErrorCursor("cursor mismatch sort columns")
+		}
+		where, args := cursorWhere(columns, current.Values, current.Backward)
+		query = query.Where(where, args...)
+	}
+	backward := current != nil && current.Backward
+
+	for _, column := range columns {
+		if column.Desc != backward {
+			query = query.Order(column.Name + " desc")
+		} else {
+			query = query.Order(column.Name + " asc")
+		}
+	}
+
+	if err := query.Limit(queryOpt.Cursor.Size + 1).Find(list).Error; err != nil {
+		return nil, ErrorQuery(err)
+	}
+
+	listV := reflect.ValueOf(list).Elem()
+	more := listV.Len() > queryOpt.Cursor.Size
+	if more {
+		listV.Set(listV.Slice(0, queryOpt.Cursor.Size))
+	}
+	if backward {
+		swap := reflect.Swapper(listV.Interface())
+		for i, j := 0, listV.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	res = &CursorRes{}
+	if listV.Len() == 0 {
+		return res, nil
+	}
+	if more || backward {
+		values, err := db.cursorValues(listV.Index(listV.Len()-1), columns)
+		if err != nil {
+			return nil, err
+		}
+		if res.Next, err = encodeCursor(values, false); err != nil {
+			return nil, err
+		}
+	}
+	if (current != nil && !backward) || (backward && more) {
+		values, err := db.cursorValues(listV.Index(0), columns)
+		if err != nil {
+			return nil, err
+		}
+		if res.Prev, err = encodeCursor(values, true); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
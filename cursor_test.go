@@ -0,0 +1,56 @@
+package mysql
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCursorEncodeDecode(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	cursor, err := encodeCursor([]interface{}{createdAt, 42, "a"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := decodeCursor(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"2024-01-02 03:04:05.000006", json.Number("42"), "a"}
+	if !reflect.DeepEqual(v.Values, want) {
+		t.Errorf("values = %#v, want %#v", v.Values, want)
+	}
+	if !v.Backward {
+		t.Error("backward = false, want true")
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for _, cursor := range []string{"!!!", "bm90IGpzb24"} {
+		if _, err := decodeCursor(cursor); err == nil {
+			t.Errorf("decodeCursor(%q) error = nil", cursor)
+		}
+	}
+}
+
+func TestCursorWhere(t *testing.T) {
+	columns := []cursorColumn{{Name: "created_at", Desc: true}, {Name: "id"}}
+	values := []interface{}{1, 2}
+	tests := []struct {
+		backward bool
+		where    string
+	}{
+		{false, "(created_at < ?) or (created_at = ? and id > ?)"},
+		{true, "(created_at > ?) or (created_at = ? and id < ?)"},
+	}
+	for _, tt := range tests {
+		where, args := cursorWhere(columns, values, tt.backward)
+		if where != tt.where {
+			t.Errorf("backward %v: where = %q, want %q", tt.backward, where, tt.where)
+		}
+		if want := []interface{}{1, 1, 2}; !reflect.DeepEqual(args, want) {
+			t.Errorf("backward %v: args = %v, want %v", tt.backward, args, want)
+		}
+	}
+}
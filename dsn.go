@@ -0,0 +1,43 @@
+package mysql
+
+import (
+	"github.com/go-sql-driver/mysql"
+	"net"
+	"strconv"
+	"time"
+)
+
+// DSN build data source name from the structured fields of Config
+func (c *Config) DSN() (string, error) {
+	port := c.Port
+	if port == 0 {
+		port = 3306
+	}
+	charset := c.Charset
+	if charset == "" {
+		charset = "utf8mb4"
+	}
+	loc := time.Local
+	if c.Loc != "" {
+		l, err := time.LoadLocation(c.Loc)
+		if err != nil {
+			return "", ErrorDSN(err)
+		}
+		loc = l
+	}
+
+	dsn := mysql.NewConfig()
+	dsn.User = c.User
+	dsn.Passwd = c.Password
+	dsn.Net = "tcp"
+	dsn.Addr = net.JoinHostPort(c.Host, strconv.Itoa(port))
+	dsn.DBName = c.Database
+	dsn.ParseTime = true
+	dsn.Loc = loc
+	dsn.Params = map[string]string{"charset": charset}
+	dsn.TLSConfig = c.TLS
+	dsn.Timeout = c.Timeout
+	dsn.ReadTimeout = c.ReadTimeout
+	dsn.WriteTimeout = c.WriteTimeout
+	return dsn.FormatDSN(), nil
+}
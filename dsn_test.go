@@ -0,0 +1,41 @@
+package mysql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfigDSN(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{
+			"defaults",
+			Config{Host: "localhost", User: "root", Password: "secret", Database: "app", Loc: "UTC"},
+			"root:secret@tcp(localhost:3306)/app?parseTime=true&charset=utf8mb4",
+		},
+		{
+			"structured fields",
+			Config{Host: "db", Port: 3307, User: "app", Database: "app", Charset: "utf8", Loc: "Asia/Shanghai", Timeout: 5 * time.Second},
+			"app@tcp(db:3307)/app?loc=Asia%2FShanghai&parseTime=true&timeout=5s&charset=utf8",
+		},
+	}
+	for _, tt := range tests {
+		got, err := tt.config.DSN()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: DSN() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestConfigDSNInvalidLoc(t *testing.T) {
+	c := Config{Host: "localhost", Loc: "Nowhere/City"}
+	if _, err := c.DSN(); err == nil {
+		t.Error("DSN() error = nil")
+	}
+}
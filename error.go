@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"errors"
+	"fmt"
 	"github.com/go-sql-driver/mysql"
 	"github.com/go-tron/base-error"
 	"github.com/thoas/go-funk"
@@ -19,10 +20,29 @@ var (
 	ErrorRecordNotUnique   = baseError.SystemFactoryStack(3, "1110", "find duplicate record")
 	ErrorRecordNotFound    = baseError.SystemFactoryStack(3, "1111", "record not found")
 	ErrorRecordNotAffected = baseError.SystemFactoryStack(3, "1112", "record for update not found")
+	ErrorStaleRecord       = baseError.SystemFactoryStack(3, "1117", "record has been modified")
 
 	ErrorPluck  = baseError.SystemFactoryStack(3, "1113", "pluck not supplied")
 	ErrorSymbol = baseError.SystemFactoryStack(3, "1114", "symbol not exists")
 	ErrorValue  = baseError.SystemFactoryStack(3, "1115")
+	ErrorCursor = baseError.SystemFactoryStack(3, "1116")
+
+	ErrorLockTransaction     = baseError.SystemFactoryStack(3, "1118", "lock must be used within a transaction")
+	ErrorTransactionRequired = baseError.SystemFactoryStack(3, "1119", "transaction is required")
+
+	ErrorConfigUnset  = baseError.SystemFactoryStack(3, "1130", "config is unset")
+	ErrorUrlUnset     = baseError.SystemFactoryStack(3, "1131", "url is unset")
+	ErrorLoggerUnset  = baseError.SystemFactoryStack(3, "1132", "logger is unset")
+	ErrorOpen         = baseError.WrapFactoryStack(3, "1133")
+	ErrorPing         = baseError.WrapFactoryStack(3, "1134")
+	ErrorLogLevel     = baseError.SystemFactoryStack(3, "1135", "log level {} is invalid")
+	ErrorDSN          = baseError.WrapFactoryStack(3, "1136")
+	ErrorClosed       = baseError.SystemFactoryStack(3, "1137", "db is closed")
+	ErrorCloseTimeout = baseError.WrapFactoryStack(3, "1138")
+	ErrorIsolation    = baseError.SystemFactoryStack(3, "1139", "isolation level {} is invalid")
+	ErrorPanic        = baseError.SystemFactoryStack(32, "1140", "transaction panic: {}")
+	ErrorPanicWrap    = baseError.WrapFactoryStack(32, "1140")
+	ErrorRollbackOnly = baseError.SystemFactoryStack(3, "1141", "transaction is marked rollback-only")
 
 	UniqueIndexErrorCodes        = []string{"1120", "1121", "1122", "1123", "1124", "1125", "1126", "1127"}
 	ErrorUniqueIndexUnset        = baseError.SystemFactoryStack(3, "1121", "data duplicate(01)")
@@ -50,12 +70,17 @@ func (db *DB) IsRecordNotAffectedError(err error) bool {
 	return IsRecordNotAffectedError(err)
 }
 
+func (db *DB) IsStaleRecordError(err error) bool {
+	return IsStaleRecordError(err)
+}
+
 func IsUniqueIndexError(err error) bool {
-	errType := reflect.TypeOf(err).String()
-	if errType == "*mysql.MySQLError" && err.(*mysql.MySQLError).Number == 1062 {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
 		return true
 	}
-	if errType == "*baseError.Error" && funk.IndexOf(UniqueIndexErrorCodes, err.(*baseError.Error).Code) != -1 {
+	var baseErr *baseError.Error
+	if errors.As(err, &baseErr) && funk.IndexOf(UniqueIndexErrorCodes, baseErr.Code) != -1 {
 		return true
 	}
 	return false
@@ -84,3 +109,66 @@ func IsRecordNotAffectedError(err error) bool {
 	}
 	return false
 }
+
+func IsStaleRecordError(err error) bool {
+	var baseErr *baseError.Error
+	return errors.As(err, &baseErr) && baseErr.Code == "1117"
+}
+
+// IsRetryableError report whether err is caused by a deadlock(1213) or a lock wait timeout(1205)
+func IsRetryableError(err error) bool {
+	for err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return mysqlErr.Number == 1213 || mysqlErr.Number == 1205
+		}
+		var causer interface{ Cause() error }
+		if !errors.As(err, &causer) {
+			return false
+		}
+		err = causer.Cause()
+	}
+	return false
+}
+
+// PanicError a panic recovered by Transaction, Unwrap return the panic value when it is an error
+// and errors.As reach Base, the 1140 error carrying the stack of the panic
+type PanicError struct {
+	Base  *baseError.Error
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return e.Base.Error()
+}
+
+func (e *PanicError) Format(s fmt.State, verb rune) {
+	e.Base.Format(s, verb)
+}
+
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+func (e *PanicError) As(target interface{}) bool {
+	if base, ok := target.(**baseError.Error); ok {
+		*base = e.Base
+		return true
+	}
+	return false
+}
+
+func newPanicError(value interface{}) *PanicError {
+	if err, ok := value.(error); ok {
+		return &PanicError{Base: ErrorPanicWrap(err), Value: value}
+	}
+	return &PanicError{Base: ErrorPanic(value), Value: value}
+}
+
+func IsPanicError(err error) bool {
+	var panicErr *PanicError
+	return errors.As(err, &panicErr)
+}
@@ -0,0 +1,85 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"strings"
+	"testing"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}
+	lockWait := &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}
+	duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"deadlock", deadlock, true},
+		{"lock wait timeout", lockWait, true},
+		{"duplicate", duplicate, false},
+		{"plain", errors.New("boom"), false},
+		{"query wrapped deadlock", ErrorQuery(deadlock), true},
+		{"query wrapped duplicate", ErrorQuery(duplicate), false},
+		{"fmt wrapped query error", fmt.Errorf("update: %w", ErrorQuery(lockWait)), true},
+		{"panic wrapped deadlock", newPanicError(deadlock), true},
+	}
+	for _, tt := range tests {
+		if got := IsRetryableError(tt.err); got != tt.want {
+			t.Errorf("%s: IsRetryableError() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPanicError(t *testing.T) {
+	original := errors.New("boom")
+	err := error(newPanicError(original))
+	if errors.Unwrap(err) != original || !errors.Is(err, original) {
+		t.Errorf("Unwrap() = %v, want the original error", errors.Unwrap(err))
+	}
+	if code := ErrorCode(err); code != "1140" {
+		t.Errorf("ErrorCode() = %s, want 1140", code)
+	}
+	if !IsPanicError(fmt.Errorf("tx: %w", err)) {
+		t.Error("IsPanicError() = false for a wrapped panic error")
+	}
+	if stack := fmt.Sprintf("%+v", err); !strings.Contains(stack, "TestPanicError") {
+		t.Errorf("stack %q does not contain the panic site", stack)
+	}
+
+	var panicErr *PanicError
+	if !errors.As(newPanicError(42), &panicErr) || panicErr.Value != 42 || panicErr.Unwrap() != nil {
+		t.Errorf("PanicError = %+v, want the raw value 42", panicErr)
+	}
+	if panicErr.Error() != "[1140] transaction panic: 42" {
+		t.Errorf("Error() = %q", panicErr.Error())
+	}
+	if IsPanicError(original) {
+		t.Error("IsPanicError() = true for a plain error")
+	}
+}
+
+func TestIsStaleRecordError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"stale", ErrorStaleRecord(), true},
+		{"batch wrapped", &BatchError{Index: 1, Err: ErrorStaleRecord()}, true},
+		{"fmt wrapped", fmt.Errorf("update: %w", ErrorStaleRecord()), true},
+		{"not affected", ErrorRecordNotAffected(), false},
+	}
+	for _, tt := range tests {
+		if got := IsStaleRecordError(tt.err); got != tt.want {
+			t.Errorf("%s: IsStaleRecordError() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if IsPanicError(nil) {
+		t.Error("IsPanicError(nil) = true")
+	}
+}
@@ -0,0 +1,59 @@
+package mysql
+
+import "reflect"
+
+func FindById[T any](db *DB, id interface{}, opts ...Option) (*T, error) {
+	model := new(T)
+	pkField := GetPKField(model)
+	if pkField.Name == "" {
+		return nil, ErrorPrimaryKeyUnset()
+	}
+	fieldV := reflect.ValueOf(model).Elem().FieldByName(pkField.Name)
+	idV := reflect.ValueOf(id)
+	if !idV.IsValid() || !idV.CanConvert(fieldV.Type()) {
+		return nil, ErrorPrimaryKeyInvalid()
+	}
+	fieldV.Set(idV.Convert(fieldV.Type()))
+	if err := db.FindById(model, opts...); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+func FindOne[T any](db *DB, opts ...Option) (*T, error) {
+	model := new(T)
+	if err := db.FindOne(model, opts...); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+func FindAll[T any](db *DB, opts ...Option) ([]T, error) {
+	list := make([]T, 0)
+	if err := db.FindAll(&list, opts...); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func FindPage[T any](db *DB, opts ...Option) (Page[T], error) {
+	list := make([]T, 0)
+	info, err := db.FindPageInfo(&list, opts...)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	return Page[T]{List: list, Total: info.Total, TotalExact: info.TotalExact, HasNext: info.HasNext}, nil
+}
+
+func Count[T any](db *DB, opts ...Option) (int, error) {
+	return db.Count(new(T), opts...)
+}
+
+func Pluck[T any, V any](db *DB, column string, opts ...Option) ([]V, error) {
+	list := make([]V, 0)
+	opts = append(opts[:len(opts):len(opts)], db.WithPluck(column, &list))
+	if err := db.FindPluck(new(T), opts...); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -9,6 +9,7 @@ require (
 	github.com/go-tron/logger v1.0.1
 	github.com/go-tron/types v1.0.1
 	github.com/jinzhu/copier v0.4.0
+	github.com/thoas/go-funk v0.9.3
 	gorm.io/driver/mysql v1.5.6
 	gorm.io/gorm v1.25.8
 )
@@ -0,0 +1,86 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"gorm.io/gorm"
+	"time"
+)
+
+type PoolStats struct {
+	MaxOpenConnections int           `json:"maxOpenConnections"`
+	OpenConnections    int           `json:"openConnections"`
+	InUse              int           `json:"inUse"`
+	Idle               int           `json:"idle"`
+	WaitCount          int64         `json:"waitCount"`
+	WaitDuration       time.Duration `json:"waitDuration"`
+	MaxIdleClosed      int64         `json:"maxIdleClosed"`
+	MaxIdleTimeClosed  int64         `json:"maxIdleTimeClosed"`
+	MaxLifetimeClosed  int64         `json:"maxLifetimeClosed"`
+}
+
+type HealthStatus struct {
+	Healthy  bool        `json:"healthy"`
+	Primary  PoolStats   `json:"primary"`
+	Replicas []PoolStats `json:"replicas"`
+}
+
+func newPoolStats(s sql.DBStats) PoolStats {
+	return PoolStats{
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDuration:       s.WaitDuration,
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
+
+func (db *DB) Stats() (PoolStats, []PoolStats, error) {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return PoolStats{}, nil, ErrorQuery(err)
+	}
+	replicas := make([]PoolStats, 0, len(db.replicas))
+	for _, replica := range db.replicas {
+		replicaDB, err := replica.DB()
+		if err != nil {
+			return PoolStats{}, nil, ErrorQuery(err)
+		}
+		replicas = append(replicas, newPoolStats(replicaDB.Stats()))
+	}
+	return newPoolStats(sqlDB.Stats()), replicas, nil
+}
+
+func (db *DB) Health(ctx context.Context) (*HealthStatus, error) {
+	primary, replicas, err := db.Stats()
+	if err != nil {
+		return &HealthStatus{}, err
+	}
+	status := &HealthStatus{Primary: primary, Replicas: replicas}
+	if err := db.ReportPoolStats(); err != nil {
+		return status, err
+	}
+
+	timeout := db.Config.HealthTimeout
+	if timeout == 0 {
+		timeout = 3 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for _, d := range append([]*gorm.DB{db.DB}, db.replicas...) {
+		sqlDB, err := d.DB()
+		if err != nil {
+			return status, ErrorPing(err)
+		}
+		if err := sqlDB.PingContext(ctx); err != nil {
+			return status, ErrorPing(err)
+		}
+	}
+	status.Healthy = true
+	return status, nil
+}
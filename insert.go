@@ -0,0 +1,101 @@
+package mysql
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+)
+
+type InsertResult struct {
+	Inserted int `json:"inserted"`
+	Ignored  int `json:"ignored"`
+	Replaced int `json:"replaced"`
+}
+
+func (r *InsertResult) add(v *InsertResult) {
+	r.Inserted += v.Inserted
+	r.Ignored += v.Ignored
+	r.Replaced += v.Replaced
+}
+
+// replaceInto turn the INSERT clause into REPLACE INTO
+type replaceInto struct{}
+
+func (replaceInto) Name() string {
+	return "INSERT"
+}
+
+func (replaceInto) Build(builder clause.Builder) {
+	clause.Insert{}.Build(builder)
+}
+
+func (replaceInto) MergeClause(c *clause.Clause) {
+	c.Name = "REPLACE"
+	c.Expression = clause.Insert{}
+}
+
+func (db *DB) insertClauses(query *gorm.DB, queryOpt *QueryOption) *gorm.DB {
+	if queryOpt.Replace {
+		return query.Clauses(replaceInto{})
+	}
+	if queryOpt.InsertIgnore {
+		return query.Clauses(clause.Insert{Modifier: "IGNORE"})
+	}
+	return query
+}
+
+// insertResult count rows of an insert statement from rows affected,
+// INSERT IGNORE affects 0 for an ignored row and REPLACE INTO affects 2 for a replaced row
+func (db *DB) insertResult(queryOpt *QueryOption, rows int, affected int64) *InsertResult {
+	if queryOpt.Replace {
+		replaced := int(affected) - rows
+		if replaced < 0 {
+			replaced = 0
+		}
+		if replaced > rows {
+			replaced = rows
+		}
+		return &InsertResult{Inserted: rows - replaced, Replaced: replaced}
+	}
+	if queryOpt.InsertIgnore {
+		return &InsertResult{Inserted: int(affected), Ignored: rows - int(affected)}
+	}
+	return &InsertResult{Inserted: int(affected)}
+}
+
+// unsetPKs return the primary keys of value which are unset before an INSERT IGNORE or REPLACE INTO
+func (db *DB) unsetPKs(model interface{}, value interface{}, queryOpt *QueryOption) []reflect.Value {
+	if !queryOpt.InsertIgnore && !queryOpt.Replace {
+		return nil
+	}
+	field := GetPKField(model)
+	if field.Name == "" {
+		return nil
+	}
+	rows := []reflect.Value{reflect.ValueOf(value)}
+	if valueV := reflect.Indirect(rows[0]); valueV.Kind() == reflect.Slice {
+		rows = rows[:0]
+		for i := 0; i < valueV.Len(); i++ {
+			rows = append(rows, valueV.Index(i))
+		}
+	}
+	keys := make([]reflect.Value, 0, len(rows))
+	for _, row := range rows {
+		key := reflect.Indirect(row).FieldByIndex(field.Index)
+		if key.CanSet() && key.IsZero() {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// resetPKs reset the keys gorm filled in order from LastInsertId,
+// they are wrong for a multi-row statement once a row is ignored or replaced, and for an ignored row
+func (db *DB) resetPKs(keys []reflect.Value, rows int, affected int64) {
+	if int(affected) == rows || (rows == 1 && affected > 0) {
+		return
+	}
+	for _, key := range keys {
+		key.Set(reflect.Zero(key.Type()))
+	}
+}
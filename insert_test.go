@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInsertResult(t *testing.T) {
+	db := &DB{}
+	tests := []struct {
+		name     string
+		queryOpt *QueryOption
+		rows     int
+		affected int64
+		want     InsertResult
+	}{
+		{"insert", &QueryOption{}, 3, 3, InsertResult{Inserted: 3}},
+		{"insert ignore", &QueryOption{InsertIgnore: true}, 3, 2, InsertResult{Inserted: 2, Ignored: 1}},
+		{"replace", &QueryOption{Replace: true}, 3, 5, InsertResult{Inserted: 1, Replaced: 2}},
+		{"replace all", &QueryOption{Replace: true}, 2, 4, InsertResult{Replaced: 2}},
+	}
+	for _, tt := range tests {
+		if got := db.insertResult(tt.queryOpt, tt.rows, tt.affected); !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("%s: insertResult() = %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+}
+
+func TestResetPKs(t *testing.T) {
+	db := &DB{}
+	type item struct {
+		Id   int `gorm:"primary_key"`
+		Name string
+	}
+	list := []item{{Name: "a"}, {Id: 7, Name: "b"}, {Name: "c"}}
+	keys := db.unsetPKs(&item{}, list, &QueryOption{InsertIgnore: true})
+	list[0].Id, list[2].Id = 10, 11
+	db.resetPKs(keys, len(list), 2)
+	if list[0].Id != 0 || list[1].Id != 7 || list[2].Id != 0 {
+		t.Errorf("ids = %d %d %d, want 0 7 0", list[0].Id, list[1].Id, list[2].Id)
+	}
+
+	row := &item{Name: "a"}
+	keys = db.unsetPKs(&item{}, row, &QueryOption{Replace: true})
+	row.Id = 12
+	db.resetPKs(keys, 1, 2)
+	if row.Id != 12 {
+		t.Errorf("id = %d, want 12 for a single inserted row", row.Id)
+	}
+
+	list = []item{{Name: "a"}, {Name: "b"}}
+	keys = db.unsetPKs(&item{}, list, &QueryOption{InsertIgnore: true})
+	list[0].Id, list[1].Id = 20, 21
+	db.resetPKs(keys, len(list), 2)
+	if list[0].Id != 20 || list[1].Id != 21 {
+		t.Errorf("ids = %d %d, want 20 21 when no row is ignored", list[0].Id, list[1].Id)
+	}
+
+	if keys := db.unsetPKs(&item{}, list, &QueryOption{}); keys != nil {
+		t.Errorf("keys = %v, want nil for a plain insert", keys)
+	}
+}
@@ -73,6 +73,21 @@ const (
 	Info = gormLogger.Info
 )
 
+// ParseLogLevel parse log level from silent, error, warn or info
+func ParseLogLevel(level string) (LogLevel, error) {
+	switch strings.ToLower(level) {
+	case "silent":
+		return Silent, nil
+	case "error":
+		return Error, nil
+	case "warn":
+		return Warn, nil
+	case "info":
+		return Info, nil
+	}
+	return 0, ErrorLogLevel(level)
+}
+
 var (
 	// Discard Discard logger will print any log to io.Discard
 	DiscardLogger = NewLogger(log.New(io.Discard, "", log.LstdFlags), gormLogger.Config{})
@@ -0,0 +1,249 @@
+package mysql
+
+import (
+	"errors"
+	"github.com/go-tron/base-error"
+	"gorm.io/gorm"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	OperationCreate = "create"
+	OperationFind   = "find"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+	OperationRaw    = "raw"
+)
+
+type MetricsCollector interface {
+	ObserveQuery(table string, operation string, elapsed time.Duration, rowsAffected int64)
+	IncError(table string, operation string, code string)
+	SetPoolStats(pool string, stats PoolStats)
+}
+
+const metricsStartKey = "mysql:metrics_start"
+
+type metricsPlugin struct {
+	collector MetricsCollector
+}
+
+func (p *metricsPlugin) Name() string {
+	return "mysql:metrics"
+}
+
+func (p *metricsPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("*").Register("mysql:metrics_before", p.before); err != nil {
+		return err
+	}
+	if err := callback.Create().After("*").Register("mysql:metrics_after", p.after(OperationCreate)); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("*").Register("mysql:metrics_before", p.before); err != nil {
+		return err
+	}
+	if err := callback.Query().After("*").Register("mysql:metrics_after", p.after(OperationFind)); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("*").Register("mysql:metrics_before", p.before); err != nil {
+		return err
+	}
+	if err := callback.Update().After("*").Register("mysql:metrics_after", p.after(OperationUpdate)); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("*").Register("mysql:metrics_before", p.before); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("*").Register("mysql:metrics_after", p.after(OperationDelete)); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("*").Register("mysql:metrics_before", p.before); err != nil {
+		return err
+	}
+	if err := callback.Row().After("*").Register("mysql:metrics_after", p.after(OperationFind)); err != nil {
+		return err
+	}
+	if err := callback.Raw().Before("*").Register("mysql:metrics_before", p.before); err != nil {
+		return err
+	}
+	return callback.Raw().After("*").Register("mysql:metrics_after", p.after(OperationRaw))
+}
+
+// before skip dry runs, e.g. the statement built for EXPLAIN by estimateCount
+func (p *metricsPlugin) before(tx *gorm.DB) {
+	if tx.DryRun {
+		return
+	}
+	tx.InstanceSet(metricsStartKey, time.Now())
+}
+
+func (p *metricsPlugin) after(operation string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		v, ok := tx.InstanceGet(metricsStartKey)
+		if !ok {
+			return
+		}
+		p.collector.ObserveQuery(tx.Statement.Table, operation, time.Since(v.(time.Time)), tx.Statement.RowsAffected)
+	}
+}
+
+// ErrorCode map err to the code of this package, 1100 for unknown query errors,
+// the DB methods count their errors with it once the errors of this package are produced
+func ErrorCode(err error) string {
+	var e *baseError.Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	if IsUniqueIndexError(err) {
+		return "1120"
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "1111"
+	}
+	return "1100"
+}
+
+func (db *DB) ReportPoolStats() error {
+	if db.Config.Metrics == nil {
+		return nil
+	}
+	primary, replicas, err := db.Stats()
+	if err != nil {
+		return err
+	}
+	db.Config.Metrics.SetPoolStats("primary", primary)
+	for i, replica := range replicas {
+		db.Config.Metrics.SetPoolStats("replica-"+strconv.Itoa(i), replica)
+	}
+	return nil
+}
+
+var DefaultHistogramBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+type Histogram struct {
+	Buckets []time.Duration `json:"buckets"`
+	Counts  []int64         `json:"counts"`
+	Count   int64           `json:"count"`
+	Sum     time.Duration   `json:"sum"`
+}
+
+func (h *Histogram) observe(elapsed time.Duration) {
+	for i, bucket := range h.Buckets {
+		if elapsed <= bucket {
+			h.Counts[i]++
+		}
+	}
+	h.Count++
+	h.Sum += elapsed
+}
+
+type MetricsKey struct {
+	Table     string
+	Operation string
+}
+
+type ErrorKey struct {
+	Table     string
+	Operation string
+	Code      string
+}
+
+// MemoryMetrics in-memory MetricsCollector, suitable for tests and debugging
+type MemoryMetrics struct {
+	mu           sync.Mutex
+	buckets      []time.Duration
+	latency      map[MetricsKey]*Histogram
+	rowsAffected map[MetricsKey]int64
+	errors       map[ErrorKey]int64
+	pools        map[string]PoolStats
+}
+
+func NewMemoryMetrics(buckets ...time.Duration) *MemoryMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultHistogramBuckets
+	}
+	return &MemoryMetrics{
+		buckets:      buckets,
+		latency:      map[MetricsKey]*Histogram{},
+		rowsAffected: map[MetricsKey]int64{},
+		errors:       map[ErrorKey]int64{},
+		pools:        map[string]PoolStats{},
+	}
+}
+
+func (m *MemoryMetrics) ObserveQuery(table string, operation string, elapsed time.Duration, rowsAffected int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := MetricsKey{Table: table, Operation: operation}
+	h, ok := m.latency[key]
+	if !ok {
+		h = &Histogram{Buckets: m.buckets, Counts: make([]int64, len(m.buckets))}
+		m.latency[key] = h
+	}
+	h.observe(elapsed)
+	if rowsAffected > 0 {
+		m.rowsAffected[key] += rowsAffected
+	}
+}
+
+func (m *MemoryMetrics) IncError(table string, operation string, code string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors[ErrorKey{Table: table, Operation: operation, Code: code}]++
+}
+
+func (m *MemoryMetrics) SetPoolStats(pool string, stats PoolStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pools[pool] = stats
+}
+
+func (m *MemoryMetrics) Latency(table string, operation string) Histogram {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.latency[MetricsKey{Table: table, Operation: operation}]
+	if !ok {
+		return Histogram{Buckets: m.buckets, Counts: make([]int64, len(m.buckets))}
+	}
+	return Histogram{Buckets: h.Buckets, Counts: append([]int64(nil), h.Counts...), Count: h.Count, Sum: h.Sum}
+}
+
+func (m *MemoryMetrics) RowsAffected(table string, operation string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rowsAffected[MetricsKey{Table: table, Operation: operation}]
+}
+
+func (m *MemoryMetrics) Errors(code string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var count int64
+	for key, v := range m.errors {
+		if key.Code == code {
+			count += v
+		}
+	}
+	return count
+}
+
+func (m *MemoryMetrics) PoolStats(pool string) (PoolStats, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats, ok := m.pools[pool]
+	return stats, ok
+}
@@ -0,0 +1,30 @@
+package mysql
+
+import (
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+type recordingMetrics struct {
+	queries int
+}
+
+func (m *recordingMetrics) ObserveQuery(table string, operation string, elapsed time.Duration, rowsAffected int64) {
+	m.queries++
+}
+func (m *recordingMetrics) IncError(table string, operation string, code string) {}
+func (m *recordingMetrics) SetPoolStats(pool string, stats PoolStats)            {}
+
+func TestMetricsSkipDryRun(t *testing.T) {
+	db := newDryRunDB(t)
+	metrics := &recordingMetrics{}
+	if err := db.Use(&metricsPlugin{collector: metrics}); err != nil {
+		t.Fatal(err)
+	}
+	var list []versionItem
+	db.Session(&gorm.Session{DryRun: true}).Find(&list)
+	if metrics.queries != 0 {
+		t.Errorf("queries = %d, want no sample for a dry run", metrics.queries)
+	}
+}
@@ -1,6 +1,7 @@
 package mysql
 
 import (
+	"fmt"
 	"github.com/go-tron/config"
 	goLogger "github.com/go-tron/logger"
 	"github.com/go-tron/types/stringUtil"
@@ -12,13 +13,38 @@ import (
 )
 
 type Config struct {
-	Dialect        string `json:"dialect"`
-	Url            string `json:"url"`
-	MaxIdleConns   int    `json:"maxIdleConns"`
-	MaxOpenConns   int    `json:"maxOpenConns"`
-	Debug          bool   `json:"debug"`
-	Logger         goLogger.Logger
-	NamingStrategy *schema.NamingStrategy
+	Dialect         string        `json:"dialect"`
+	Url             string        `json:"url"`
+	Replicas        []string      `json:"replicas"`
+	Host            string        `json:"host"`
+	Port            int           `json:"port"`
+	User            string        `json:"user"`
+	Password        string        `json:"password"`
+	Database        string        `json:"database"`
+	Charset         string        `json:"charset"`
+	Loc             string        `json:"loc"`
+	TLS             string        `json:"tls"`
+	Timeout         time.Duration `json:"timeout"`
+	ReadTimeout     time.Duration `json:"readTimeout"`
+	WriteTimeout    time.Duration `json:"writeTimeout"`
+	MaxIdleConns    int           `json:"maxIdleConns"`
+	MaxOpenConns    int           `json:"maxOpenConns"`
+	ConnMaxLifetime time.Duration `json:"connMaxLifetime"`
+	ConnMaxIdleTime time.Duration `json:"connMaxIdleTime"`
+	SlowThreshold   time.Duration `json:"slowThreshold"`
+	LogLevel        string        `json:"logLevel"`
+	Debug           bool          `json:"debug"`
+	PingAttempts    int           `json:"pingAttempts"`
+	PingBackoff     time.Duration `json:"pingBackoff"`
+	HealthTimeout   time.Duration `json:"healthTimeout"`
+	TxRetries       int           `json:"txRetries"`
+	TxRetryBackoff  time.Duration `json:"txRetryBackoff"`
+	TxIsolation     string        `json:"txIsolation"`
+	TxReadOnly      bool          `json:"txReadOnly"`
+	Logger          goLogger.Logger
+	Metrics         MetricsCollector
+	Tracer          Tracer
+	NamingStrategy  *schema.NamingStrategy
 }
 
 type ConfigOption func(*Config)
@@ -28,31 +54,119 @@ func WithNamingStrategy(val *schema.NamingStrategy) ConfigOption {
 		config.NamingStrategy = val
 	}
 }
+func WithReplicas(val ...string) ConfigOption {
+	return func(config *Config) {
+		config.Replicas = append(config.Replicas, val...)
+	}
+}
+
+func WithMetrics(val MetricsCollector) ConfigOption {
+	return func(config *Config) {
+		config.Metrics = val
+	}
+}
+
+func WithTracer(val Tracer) ConfigOption {
+	return func(config *Config) {
+		config.Tracer = val
+	}
+}
+
+func WithPingRetry(attempts int, backoff time.Duration) ConfigOption {
+	return func(config *Config) {
+		config.PingAttempts = attempts
+		config.PingBackoff = backoff
+	}
+}
+
+func getConfig(c *config.Config) *Config {
+	return &Config{
+		Dialect:         c.GetString("database.dialect"),
+		Url:             c.GetString("database.url"),
+		Replicas:        c.GetStringSlice("database.replicas"),
+		Host:            c.GetString("database.host"),
+		Port:            c.GetInt("database.port"),
+		User:            c.GetString("database.user"),
+		Password:        c.GetString("database.password"),
+		Database:        c.GetString("database.database"),
+		Charset:         c.GetString("database.charset"),
+		Loc:             c.GetString("database.loc"),
+		TLS:             c.GetString("database.tls"),
+		Timeout:         c.GetDuration("database.timeout"),
+		ReadTimeout:     c.GetDuration("database.readTimeout"),
+		WriteTimeout:    c.GetDuration("database.writeTimeout"),
+		MaxIdleConns:    c.GetInt("database.maxIdleConns"),
+		MaxOpenConns:    c.GetInt("database.maxOpenConns"),
+		ConnMaxLifetime: c.GetDuration("database.connMaxLifetime"),
+		ConnMaxIdleTime: c.GetDuration("database.connMaxIdleTime"),
+		SlowThreshold:   c.GetDuration("database.slowThreshold"),
+		LogLevel:        c.GetString("database.logLevel"),
+		Debug:           c.GetBool("database.debug"),
+		PingAttempts:    c.GetInt("database.pingAttempts"),
+		PingBackoff:     c.GetDuration("database.pingBackoff"),
+		HealthTimeout:   c.GetDuration("database.healthTimeout"),
+		TxRetries:       c.GetInt("database.txRetries"),
+		TxRetryBackoff:  c.GetDuration("database.txRetryBackoff"),
+		TxIsolation:     c.GetString("database.txIsolation"),
+		TxReadOnly:      c.GetBool("database.txReadOnly"),
+		Logger:          goLogger.NewZapWithConfig(c, "mysql", "error"),
+	}
+}
+
 func NewWithConfig(c *config.Config, opts ...ConfigOption) *DB {
-	return New(&Config{
-		Dialect:      c.GetString("database.dialect"),
-		Url:          c.GetString("database.url"),
-		MaxIdleConns: c.GetInt("database.maxIdleConns"),
-		MaxOpenConns: c.GetInt("database.maxOpenConns"),
-		Debug:        c.GetBool("database.debug"),
-		Logger:       goLogger.NewZapWithConfig(c, "mysql", "error"),
-	}, opts...)
+	return New(getConfig(c), opts...)
+}
+
+func OpenWithConfig(c *config.Config, opts ...ConfigOption) (*DB, error) {
+	return Open(getConfig(c), opts...)
 }
 
 func New(c *Config, opts ...ConfigOption) *DB {
+	db, err := Open(c, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+func Open(c *Config, opts ...ConfigOption) (*DB, error) {
 	if c == nil {
-		panic("c 必须设置")
+		return nil, ErrorConfigUnset()
 	}
 	for _, apply := range opts {
 		if apply != nil {
 			apply(c)
 		}
 	}
+	if c.Url == "" && c.Host != "" {
+		dsn, err := c.DSN()
+		if err != nil {
+			return nil, err
+		}
+		c.Url = dsn
+	}
 	if c.Url == "" {
-		panic("Url 必须设置")
+		return nil, ErrorUrlUnset()
 	}
 	if c.Logger == nil {
-		panic("Logger 必须设置")
+		return nil, ErrorLoggerUnset()
+	}
+
+	if c.ConnMaxLifetime == 0 {
+		c.ConnMaxLifetime = time.Minute * 10
+	}
+	if c.SlowThreshold == 0 {
+		c.SlowThreshold = 200 * time.Millisecond
+	}
+	if c.LogLevel == "" {
+		c.LogLevel = "error"
+	}
+	logLevel, err := ParseLogLevel(c.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ParseIsolationLevel(c.TxIsolation); err != nil {
+		return nil, err
 	}
 
 	if c.NamingStrategy == nil {
@@ -60,6 +174,52 @@ func New(c *Config, opts ...ConfigOption) *DB {
 			SingularTable: true,
 		}
 	}
+	attempts := c.PingAttempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+	backoff := c.PingBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	for i := 1; ; i++ {
+		db, err := connectAll(c, logLevel)
+		if err == nil {
+			return db, nil
+		}
+		if i >= attempts {
+			return nil, err
+		}
+		c.Logger.Error(fmt.Sprintf("connect failed, retry %d/%d in %v: %v", i, attempts-1, backoff, err))
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func connectAll(c *Config, logLevel LogLevel) (*DB, error) {
+	primary, err := connect(c, c.Url, logLevel)
+	if err != nil {
+		return nil, err
+	}
+	db := &DB{Config: c, DB: primary, shutdown: &shutdown{}}
+	for _, url := range c.Replicas {
+		replica, err := connect(c, url, logLevel)
+		if err != nil {
+			db.closeAll()
+			return nil, err
+		}
+		db.replicas = append(db.replicas, replica)
+	}
+	for _, d := range append([]*gorm.DB{db.DB}, db.replicas...) {
+		if err := db.shutdown.register(d); err != nil {
+			db.closeAll()
+			return nil, ErrorOpen(err)
+		}
+	}
+	return db, nil
+}
+
+func connect(c *Config, url string, logLevel LogLevel) (*gorm.DB, error) {
 	conf := &gorm.Config{
 		NamingStrategy: c.NamingStrategy,
 	}
@@ -67,25 +227,42 @@ func New(c *Config, opts ...ConfigOption) *DB {
 		conf.Logger = DefaultLogger.LogMode(Info)
 	} else {
 		conf.Logger = NewLogger(&DBLogger{c.Logger}, gormLogger.Config{
-			SlowThreshold:             200 * time.Millisecond,
-			LogLevel:                  Error,
+			SlowThreshold:             c.SlowThreshold,
+			LogLevel:                  logLevel,
 			IgnoreRecordNotFoundError: true,
 			Colorful:                  false,
 		})
 	}
-	db, err := gorm.Open(mysql.Open(c.Url), conf)
+	db, err := gorm.Open(mysql.Open(url), conf)
 	if err != nil {
-		panic(err)
+		return nil, ErrorOpen(err)
 	}
-	dbConfig, _ := db.DB()
-	dbConfig.SetConnMaxLifetime(time.Minute * 10)
+	dbConfig, err := db.DB()
+	if err != nil {
+		return nil, ErrorOpen(err)
+	}
+	dbConfig.SetConnMaxLifetime(c.ConnMaxLifetime)
+	dbConfig.SetConnMaxIdleTime(c.ConnMaxIdleTime)
 	dbConfig.SetMaxIdleConns(c.MaxIdleConns)
 	dbConfig.SetMaxOpenConns(c.MaxOpenConns)
 
 	if err := dbConfig.Ping(); err != nil {
-		panic(err)
+		dbConfig.Close()
+		return nil, ErrorPing(err)
+	}
+	if c.Metrics != nil {
+		if err := db.Use(&metricsPlugin{collector: c.Metrics}); err != nil {
+			dbConfig.Close()
+			return nil, ErrorOpen(err)
+		}
+	}
+	if c.Tracer != nil {
+		if err := db.Use(&tracingPlugin{tracer: c.Tracer}); err != nil {
+			dbConfig.Close()
+			return nil, ErrorOpen(err)
+		}
 	}
-	return &DB{Config: c, DB: db}
+	return db, nil
 }
 
 type CamelCaseReplacer struct {
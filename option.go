@@ -1,8 +1,10 @@
 package mysql
 
 import (
+	"context"
 	"github.com/go-tron/types/pageable"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"reflect"
 )
 
@@ -14,6 +16,7 @@ type Select struct {
 }
 type QueryOption struct {
 	DB               *gorm.DB
+	Context          context.Context
 	Table            string
 	PrimaryKey       string
 	Updates          map[string]interface{}
@@ -28,11 +31,26 @@ type QueryOption struct {
 	Limit            int
 	Offset           int
 	Pageable         *pageable.Pageable
+	Cursor           *Cursor
+	CountMode        CountMode
+	ParallelCount    bool
+	BatchTransaction bool
+	InsertIgnore     bool
+	Replace          bool
+	Lock             string
+	LockOption       string
+	UpsertColumns    []string
+	UpsertExprs      map[string]interface{}
 	Sort             []string
 	Pluck            []interface{}
 	First            bool
 	Last             bool
 	WithDeleted      bool
+	Primary          bool
+	CurrentVersion   bool
+	replica          bool
+	versioned        bool
+	version          interface{}
 	IgnoreNotFound   bool
 	MustAffected     bool
 	ErrorNotFound    error
@@ -45,6 +63,11 @@ func (db *DB) WithDB(val *gorm.DB) Option {
 		opts.DB = val
 	}
 }
+func (db *DB) WithCtx(val context.Context) Option {
+	return func(opts *QueryOption) {
+		opts.Context = val
+	}
+}
 func (db *DB) WithTable(val string) Option {
 	return func(opts *QueryOption) {
 		opts.Table = val
@@ -186,6 +209,74 @@ func (db *DB) WithPageable(val *pageable.Pageable) Option {
 		opts.Pageable = val
 	}
 }
+
+// WithCurrentVersion guard a versioned update with the version stored in the row instead of the model's, for a model built from an id
+func (db *DB) WithCurrentVersion() Option {
+	return func(opts *QueryOption) {
+		opts.CurrentVersion = true
+	}
+}
+func (db *DB) WithoutCount() Option {
+	return func(opts *QueryOption) {
+		opts.CountMode = CountNone
+	}
+}
+func (db *DB) WithHasNext() Option {
+	return func(opts *QueryOption) {
+		opts.CountMode = CountHasNext
+	}
+}
+func (db *DB) WithEstimatedCount() Option {
+	return func(opts *QueryOption) {
+		opts.CountMode = CountEstimated
+	}
+}
+func (db *DB) WithParallelCount() Option {
+	return func(opts *QueryOption) {
+		opts.ParallelCount = true
+	}
+}
+func (db *DB) WithBatchTransaction() Option {
+	return func(opts *QueryOption) {
+		opts.BatchTransaction = true
+	}
+}
+
+// WithInsertIgnore insert with INSERT IGNORE, the auto increment keys are left unset once a row is ignored
+func (db *DB) WithInsertIgnore() Option {
+	return func(opts *QueryOption) {
+		opts.InsertIgnore = true
+	}
+}
+
+// WithReplace insert with REPLACE INTO, the auto increment keys of a multi-row insert are left unset once a row is replaced
+func (db *DB) WithReplace() Option {
+	return func(opts *QueryOption) {
+		opts.Replace = true
+	}
+}
+func (db *DB) WithUpsertColumns(val ...string) Option {
+	return func(opts *QueryOption) {
+		opts.UpsertColumns = append(opts.UpsertColumns, val...)
+	}
+}
+func (db *DB) WithUpsertExpressions(val map[string]interface{}) Option {
+	return func(opts *QueryOption) {
+		if opts.UpsertExprs == nil {
+			opts.UpsertExprs = map[string]interface{}{}
+		}
+		for k, v := range val {
+			opts.UpsertExprs[k] = v
+		}
+	}
+}
+func (db *DB) WithCursor(after string, size int, sortColumns ...string) Option {
+	return func(opts *QueryOption) {
+		opts.Cursor = &Cursor{
+			After: after, Size: size, Sort: sortColumns,
+		}
+	}
+}
 func (db *DB) WithSort(val ...string) Option {
 	return func(opts *QueryOption) {
 		opts.Sort = append(opts.Sort, val...)
@@ -196,6 +287,26 @@ func (db *DB) WithPluck(column string, val interface{}) Option {
 		opts.Pluck = []interface{}{column, val}
 	}
 }
+func (db *DB) WithLockForUpdate() Option {
+	return func(opts *QueryOption) {
+		opts.Lock = clause.LockingStrengthUpdate
+	}
+}
+func (db *DB) WithLockForShare() Option {
+	return func(opts *QueryOption) {
+		opts.Lock = clause.LockingStrengthShare
+	}
+}
+func (db *DB) WithSkipLocked() Option {
+	return func(opts *QueryOption) {
+		opts.LockOption = clause.LockingOptionsSkipLocked
+	}
+}
+func (db *DB) WithNoWait() Option {
+	return func(opts *QueryOption) {
+		opts.LockOption = clause.LockingOptionsNoWait
+	}
+}
 func (db *DB) WithFirst() Option {
 	return func(opts *QueryOption) {
 		opts.First = true
@@ -211,6 +322,16 @@ func (db *DB) WithDeleted() Option {
 		opts.WithDeleted = true
 	}
 }
+func (db *DB) WithPrimary() Option {
+	return func(opts *QueryOption) {
+		opts.Primary = true
+	}
+}
+func (db *DB) withReplica() Option {
+	return func(opts *QueryOption) {
+		opts.replica = true
+	}
+}
 func (db *DB) WithIgnoreNotFound() Option {
 	return func(opts *QueryOption) {
 		opts.IgnoreNotFound = true
@@ -248,10 +369,18 @@ func (db *DB) QueryBuilder(model interface{}, opts ...Option) (*gorm.DB, *QueryO
 	var query *gorm.DB
 	if queryOption.DB != nil {
 		query = queryOption.DB
+	} else if state := db.txStateFromContext(queryOption.Context); state != nil && !inTransaction(db.DB) {
+		query = state.tx
+	} else if queryOption.replica && !queryOption.Primary {
+		query = db.replica()
 	} else {
 		query = db.DB
 	}
 
+	if queryOption.Context != nil {
+		query = query.WithContext(queryOption.Context)
+	}
+
 	if queryOption.Table != "" {
 		query = query.Table(queryOption.Table)
 	} else if model != nil {
@@ -360,9 +489,28 @@ func (db *DB) QueryBuilder(model interface{}, opts ...Option) (*gorm.DB, *QueryO
 		query = db.Filters(query, queryOption.Filters)
 	}
 
+	if queryOption.Lock != "" || queryOption.LockOption != "" {
+		strength := queryOption.Lock
+		if strength == "" {
+			strength = clause.LockingStrengthUpdate
+		}
+		query = query.Clauses(clause.Locking{Strength: strength, Options: queryOption.LockOption})
+	}
+
 	return query, queryOption
 }
 
+func (db *DB) readQueryBuilder(model interface{}, opts ...Option) (*gorm.DB, *QueryOption) {
+	return db.QueryBuilder(model, append([]Option{db.withReplica()}, opts...)...)
+}
+
+func (db *DB) checkLock(query *gorm.DB, queryOption *QueryOption) error {
+	if (queryOption.Lock != "" || queryOption.LockOption != "") && !inTransaction(query) {
+		return ErrorLockTransaction()
+	}
+	return nil
+}
+
 func (db *DB) CountBuilder(query *gorm.DB) *gorm.DB {
 	return query.Select("*").Limit(-1).Offset(-1)
 }
@@ -0,0 +1,39 @@
+package mysql
+
+import (
+	"errors"
+	"github.com/go-tron/base-error"
+	"testing"
+)
+
+func TestLockRequiresTransaction(t *testing.T) {
+	db := newDryRunDB(t)
+	lock := db.WithLockForUpdate()
+	var list []versionItem
+	calls := map[string]func() error{
+		"Count": func() error {
+			_, err := db.Count(&versionItem{}, lock)
+			return err
+		},
+		"Find": func() error { return db.Find(&versionItem{}, lock) },
+		"FindAllWithModel": func() error {
+			_, err := db.FindAllWithModel(&versionItem{}, lock)
+			return err
+		},
+		"FindPage": func() error {
+			_, err := db.FindPage(&list, lock)
+			return err
+		},
+		"FindPluck": func() error { return db.FindPluck(&versionItem{}, lock) },
+		"FindCursorPage": func() error {
+			_, err := db.FindCursorPage(&list, lock)
+			return err
+		},
+	}
+	for name, call := range calls {
+		var baseErr *baseError.Error
+		if err := call(); !errors.As(err, &baseErr) || baseErr.Code != "1118" {
+			t.Errorf("%s: err = %v, want lock transaction error", name, err)
+		}
+	}
+}
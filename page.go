@@ -0,0 +1,152 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"reflect"
+	"strconv"
+)
+
+type CountMode int
+
+const (
+	// CountExact run COUNT(*) for the total, default
+	CountExact CountMode = iota
+	// CountNone skip the total
+	CountNone
+	// CountHasNext fetch Size+1 rows to compute HasNext instead of the total
+	CountHasNext
+	// CountEstimated use the row estimate of EXPLAIN as the total
+	CountEstimated
+)
+
+type PageInfo struct {
+	Total      int  `json:"total"`
+	TotalExact bool `json:"totalExact"`
+	HasNext    bool `json:"hasNext"`
+}
+
+func (db *DB) page(list interface{}, query *gorm.DB, queryOpt *QueryOption) (*PageInfo, error) {
+	info := &PageInfo{}
+	pageable := queryOpt.Pageable
+	if pageable == nil {
+		if err := query.Find(list).Error; err != nil {
+			return nil, ErrorQuery(err)
+		}
+		return info, nil
+	}
+
+	if queryOpt.CountMode == CountHasNext && pageable.Size > 0 {
+		if err := query.Limit(pageable.Size + 1).Find(list).Error; err != nil {
+			return nil, ErrorQuery(err)
+		}
+		listV := reflect.ValueOf(list).Elem()
+		if listV.Len() > pageable.Size {
+			info.HasNext = true
+			listV.Set(listV.Slice(0, pageable.Size))
+		}
+		info.Total = (pageable.Page-1)*pageable.Size + listV.Len()
+		return info, nil
+	}
+
+	if queryOpt.ParallelCount && queryOpt.CountMode == CountExact && !inTransaction(query) {
+		total, err := db.findAndCount(list, query)
+		if err != nil {
+			return nil, err
+		}
+		info.Total = int(total)
+		info.TotalExact = true
+		info.HasNext = pageable.Size > 0 && int64(pageable.Page*pageable.Size) < total
+		return info, nil
+	}
+
+	if err := query.Find(list).Error; err != nil {
+		return nil, ErrorQuery(err)
+	}
+
+	var total int64 = 0
+	switch queryOpt.CountMode {
+	case CountNone:
+		return info, nil
+	case CountEstimated:
+		estimated, err := db.estimateCount(list, query)
+		if err != nil {
+			return nil, err
+		}
+		total = estimated
+	default:
+		if err := db.CountBuilder(query).Count(&total).Error; err != nil {
+			return nil, err
+		}
+		info.TotalExact = true
+	}
+	info.Total = int(total)
+	info.HasNext = pageable.Size > 0 && int64(pageable.Page*pageable.Size) < total
+	return info, nil
+}
+
+// findAndCount run the list and the count query concurrently on separate pooled connections,
+// the first error cancels the other one
+func (db *DB) findAndCount(list interface{}, query *gorm.DB) (int64, error) {
+	ctx := query.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var total int64 = 0
+	errs := make(chan error, 2)
+	go func() {
+		if err := query.Session(&gorm.Session{Context: ctx}).Find(list).Error; err != nil {
+			errs <- ErrorQuery(err)
+			return
+		}
+		errs <- nil
+	}()
+	go func() {
+		errs <- db.CountBuilder(query.Session(&gorm.Session{Context: ctx})).Count(&total).Error
+	}()
+
+	var result error
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil && result == nil {
+			result = err
+			cancel()
+		}
+	}
+	return total, result
+}
+
+func inTransaction(query *gorm.DB) bool {
+	_, ok := query.Statement.ConnPool.(gorm.TxCommitter)
+	return ok
+}
+
+// estimateCount read the estimated rows of the count query from EXPLAIN
+func (db *DB) estimateCount(list interface{}, query *gorm.DB) (int64, error) {
+	stmt := db.CountBuilder(query).Session(&gorm.Session{DryRun: true}).Find(list).Statement
+	var plans []map[string]interface{}
+	if err := query.Session(&gorm.Session{NewDB: true}).Raw("EXPLAIN "+stmt.SQL.String(), stmt.Vars...).Scan(&plans).Error; err != nil {
+		return 0, ErrorQuery(err)
+	}
+	if len(plans) == 0 {
+		return 0, nil
+	}
+	rows, err := strconv.ParseFloat(fmt.Sprint(explainValue(plans[0]["rows"])), 64)
+	if err != nil {
+		return 0, nil
+	}
+	if filtered, err := strconv.ParseFloat(fmt.Sprint(explainValue(plans[0]["filtered"])), 64); err == nil {
+		rows = rows * filtered / 100
+	}
+	return int64(rows), nil
+}
+
+func explainValue(v interface{}) interface{} {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v
+}
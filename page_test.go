@@ -0,0 +1,38 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindAndCountCancelsOnError(t *testing.T) {
+	db, conn := newFakeDB(t)
+	canceled := make(chan struct{})
+	conn.query = func(ctx context.Context, query string) (*sql.Rows, error) {
+		if !strings.Contains(query, "count(*)") {
+			return nil, errors.New("boom")
+		}
+		select {
+		case <-ctx.Done():
+			close(canceled)
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+			return nil, errors.New("count was not canceled")
+		}
+	}
+
+	var list []versionItem
+	_, err := db.findAndCount(&list, db.DB.Model(&versionItem{}))
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("err = %v, want the list query error", err)
+	}
+	select {
+	case <-canceled:
+	default:
+		t.Error("count query was not canceled")
+	}
+}
@@ -14,27 +14,48 @@ import (
 type DB struct {
 	Config *Config
 	*gorm.DB
+	replicas     []*gorm.DB
+	replicaIndex uint32
+	shutdown     *shutdown
+	parent       *DB
 }
 
-func (db *DB) Create(model interface{}, opts ...Option) error {
+func (db *DB) Create(model interface{}, opts ...Option) (err error) {
+	opts, end := db.observe("Create", OperationCreate, model, opts)
+	defer end(&err)
+	_, err = db.CreateWithResult(model, opts...)
+	return err
+}
+
+func (db *DB) CreateWithResult(model interface{}, opts ...Option) (result *InsertResult, err error) {
+	opts, end := db.observe("CreateWithResult", OperationCreate, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
-		return ErrorModel()
+		return nil, ErrorModel()
 	}
-	query, _ := db.QueryBuilder(model, opts...)
-	if err := query.Create(model).Error; err != nil {
+	query, queryOpt := db.QueryBuilder(model, opts...)
+	keys := db.unsetPKs(model, model, queryOpt)
+	query = db.insertClauses(query, queryOpt).Create(model)
+	if err := query.Error; err != nil {
 		if db.IsUniqueIndexError(err) {
-			return GetUniqueIndexError(model, err.Error())
+			return nil, GetUniqueIndexError(model, err.Error())
 		}
-		return ErrorQuery(err)
+		return nil, ErrorQuery(err)
 	}
-	return nil
+	db.resetPKs(keys, 1, query.RowsAffected)
+	return db.insertResult(queryOpt, 1, query.RowsAffected), nil
 }
 
-func (db *DB) Count(model interface{}, opts ...Option) (int, error) {
+func (db *DB) Count(model interface{}, opts ...Option) (total int, err error) {
+	opts, end := db.observe("Count", OperationFind, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return 0, ErrorModel()
 	}
-	query, _ := db.QueryBuilder(model, opts...)
+	query, queryOpt := db.readQueryBuilder(model, opts...)
+	if err := db.checkLock(query, queryOpt); err != nil {
+		return 0, err
+	}
 	var count int64 = 0
 	if err := db.CountBuilder(query).Count(&count).Error; err != nil {
 		return 0, ErrorQuery(err)
@@ -42,16 +63,21 @@ func (db *DB) Count(model interface{}, opts ...Option) (int, error) {
 	return int(count), nil
 }
 
-func (db *DB) FindById(model interface{}, opts ...Option) error {
+func (db *DB) FindById(model interface{}, opts ...Option) (err error) {
+	opts, end := db.observe("FindById", OperationFind, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return ErrorModel()
 	}
 
-	query, queryOpt := db.QueryBuilder(model, opts...)
+	query, queryOpt := db.readQueryBuilder(model, opts...)
 
 	if _, err := db.validatePK(model, queryOpt.PrimaryKey); err != nil {
 		return err
 	}
+	if err := db.checkLock(query, queryOpt); err != nil {
+		return err
+	}
 
 	if err := query.Take(model).Error; err != nil {
 		if db.IsRecordNotFoundError(err) {
@@ -70,12 +96,17 @@ func (db *DB) FindById(model interface{}, opts ...Option) error {
 	return nil
 }
 
-func (db *DB) FindOne(model interface{}, opts ...Option) error {
+func (db *DB) FindOne(model interface{}, opts ...Option) (err error) {
+	opts, end := db.observe("FindOne", OperationFind, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return ErrorModel()
 	}
 
-	query, queryOpt := db.QueryBuilder(model, opts...)
+	query, queryOpt := db.readQueryBuilder(model, opts...)
+	if err := db.checkLock(query, queryOpt); err != nil {
+		return err
+	}
 
 	list := reflect.New(reflect.SliceOf(reflect.TypeOf(model).Elem()))
 	if err := query.Find(list.Interface()).Error; err != nil {
@@ -104,11 +135,13 @@ func (db *DB) FindOne(model interface{}, opts ...Option) error {
 	return nil
 }
 
-func (db *DB) CloneById(model interface{}, opts ...Option) (interface{}, error) {
+func (db *DB) CloneById(model interface{}, opts ...Option) (clone interface{}, err error) {
+	opts, end := db.observe("CloneById", OperationFind, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return nil, ErrorModel()
 	}
-	clone := reflect.New(reflect.TypeOf(model).Elem()).Interface()
+	clone = reflect.New(reflect.TypeOf(model).Elem()).Interface()
 
 	pk, err := db.validatePK(model)
 	if err != nil {
@@ -123,11 +156,13 @@ func (db *DB) CloneById(model interface{}, opts ...Option) (interface{}, error)
 	return clone, nil
 }
 
-func (db *DB) CloneOne(model interface{}, opts ...Option) (interface{}, error) {
+func (db *DB) CloneOne(model interface{}, opts ...Option) (clone interface{}, err error) {
+	opts, end := db.observe("CloneOne", OperationFind, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return nil, ErrorModel()
 	}
-	clone := reflect.New(reflect.TypeOf(model).Elem()).Interface()
+	clone = reflect.New(reflect.TypeOf(model).Elem()).Interface()
 	if err := copier.Copy(clone, model); err != nil {
 		return nil, err
 	}
@@ -151,7 +186,9 @@ func (db *DB) delete(model interface{}, query *gorm.DB, queryOpt *QueryOption) e
 	return nil
 }
 
-func (db *DB) DeleteAll(model interface{}, opts ...Option) error {
+func (db *DB) DeleteAll(model interface{}, opts ...Option) (err error) {
+	opts, end := db.observe("DeleteAll", OperationDelete, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return ErrorModel()
 	}
@@ -159,7 +196,9 @@ func (db *DB) DeleteAll(model interface{}, opts ...Option) error {
 	return db.delete(model, query, queryOpt)
 }
 
-func (db *DB) DeleteById(model interface{}, opts ...Option) error {
+func (db *DB) DeleteById(model interface{}, opts ...Option) (err error) {
+	opts, end := db.observe("DeleteById", OperationDelete, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return ErrorModel()
 	}
@@ -170,7 +209,9 @@ func (db *DB) DeleteById(model interface{}, opts ...Option) error {
 	return db.delete(model, query, queryOpt)
 }
 
-func (db *DB) DeleteOne(model interface{}, opts ...Option) error {
+func (db *DB) DeleteOne(model interface{}, opts ...Option) (err error) {
+	opts, end := db.observe("DeleteOne", OperationDelete, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return ErrorModel()
 	}
@@ -219,16 +260,24 @@ func (db *DB) update(model interface{}, updates interface{}, query *gorm.DB, que
 		return 0, ErrorQuery(err)
 	}
 
+	if query.RowsAffected == 0 && queryOpt.versioned && queryOpt.version != nil {
+		return 0, ErrorStaleRecord()
+	}
 	if query.RowsAffected == 0 && queryOpt.MustAffected {
 		if err := queryOpt.ErrorNotAffected; err != nil {
 			return 0, err
 		}
 		return 0, GetRecordNotAffectedError(model)
 	}
+	if queryOpt.versioned {
+		db.bumpVersion(model, queryOpt)
+	}
 	return int(query.RowsAffected), nil
 }
 
-func (db *DB) UpdateAll(model interface{}, updates interface{}, opts ...Option) (int, error) {
+func (db *DB) UpdateAll(model interface{}, updates interface{}, opts ...Option) (affected int, err error) {
+	opts, end := db.observe("UpdateAll", OperationUpdate, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return 0, ErrorModel()
 	}
@@ -236,7 +285,9 @@ func (db *DB) UpdateAll(model interface{}, updates interface{}, opts ...Option)
 	return db.update(model, updates, query, queryOpt)
 }
 
-func (db *DB) UpdateById(model interface{}, values interface{}, opts ...Option) error {
+func (db *DB) UpdateById(model interface{}, values interface{}, opts ...Option) (err error) {
+	opts, end := db.observe("UpdateById", OperationUpdate, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return ErrorModel()
 	}
@@ -248,15 +299,22 @@ func (db *DB) UpdateById(model interface{}, values interface{}, opts ...Option)
 	if _, err := db.validatePK(model, queryOpt.PrimaryKey); err != nil {
 		return err
 	}
-	_, err := db.update(model, values, query, queryOpt)
+	var expected interface{}
+	if field, ok := GetVersionField(model); ok && !queryOpt.CurrentVersion {
+		expected = getVersion(model, field).Interface()
+	}
+	values, query = db.versionLock(model, values, expected, query, queryOpt)
+	_, err = db.update(model, values, query, queryOpt)
 	return err
 }
 
-func (db *DB) UpdateByIdWithChangedValues(model interface{}, values interface{}, opts ...Option) (map[string]interface{}, error) {
+func (db *DB) UpdateByIdWithChangedValues(model interface{}, values interface{}, opts ...Option) (changed map[string]interface{}, err error) {
+	opts, end := db.observe("UpdateByIdWithChangedValues", OperationUpdate, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return nil, ErrorModel()
 	}
-	clone, err := db.CloneById(model, opts...)
+	clone, err := db.CloneById(model, append([]Option{db.WithPrimary()}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -267,14 +325,29 @@ func (db *DB) UpdateByIdWithChangedValues(model interface{}, values interface{},
 	if err != nil {
 		return nil, err
 	}
-	_, err = db.UpdateAll(model, updates, opts...)
-	if err != nil {
+	field, ok := GetVersionField(model)
+	if !ok {
+		_, err = db.UpdateAll(model, updates, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return updates, nil
+	}
+	query, queryOpt := db.QueryBuilder(model, opts...)
+	expected := getVersion(model, field).Interface()
+	if queryOpt.CurrentVersion {
+		expected = getVersion(clone, field).Interface()
+	}
+	versioned, query := db.versionLock(model, updates, expected, query, queryOpt)
+	if _, err := db.update(model, versioned, query, queryOpt); err != nil {
 		return nil, err
 	}
 	return updates, nil
 }
 
-func (db *DB) UpdateOne(model interface{}, values interface{}, opts ...Option) error {
+func (db *DB) UpdateOne(model interface{}, values interface{}, opts ...Option) (err error) {
+	opts, end := db.observe("UpdateOne", OperationUpdate, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return ErrorModel()
 	}
@@ -304,15 +377,17 @@ func (db *DB) UpdateOne(model interface{}, values interface{}, opts ...Option) e
 		}
 		return ErrorRecordNotUnique()
 	}
-	_, err := db.update(model, values, query, queryOpt)
+	_, err = db.update(model, values, query, queryOpt)
 	return err
 }
 
-func (db *DB) UpdateOneWithChangedValues(model interface{}, values interface{}, opts ...Option) (map[string]interface{}, error) {
+func (db *DB) UpdateOneWithChangedValues(model interface{}, values interface{}, opts ...Option) (changed map[string]interface{}, err error) {
+	opts, end := db.observe("UpdateOneWithChangedValues", OperationUpdate, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return nil, ErrorModel()
 	}
-	clone, err := db.CloneOne(model, opts...)
+	clone, err := db.CloneOne(model, append([]Option{db.WithPrimary()}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -330,11 +405,16 @@ func (db *DB) UpdateOneWithChangedValues(model interface{}, values interface{},
 	return updates, nil
 }
 
-func (db *DB) Find(model interface{}, opts ...Option) error {
+func (db *DB) Find(model interface{}, opts ...Option) (err error) {
+	opts, end := db.observe("Find", OperationFind, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return ErrorModel()
 	}
-	query, queryOpt := db.QueryBuilder(model, opts...)
+	query, queryOpt := db.readQueryBuilder(model, opts...)
+	if err := db.checkLock(query, queryOpt); err != nil {
+		return err
+	}
 
 	if queryOpt.First {
 		query.First(model)
@@ -361,11 +441,16 @@ func (db *DB) Find(model interface{}, opts ...Option) error {
 	return nil
 }
 
-func (db *DB) FindAllWithModel(model interface{}, opts ...Option) (interface{}, error) {
+func (db *DB) FindAllWithModel(model interface{}, opts ...Option) (models interface{}, err error) {
+	opts, end := db.observe("FindAllWithModel", OperationFind, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return nil, ErrorModel()
 	}
-	query, queryOpt := db.QueryBuilder(model, opts...)
+	query, queryOpt := db.readQueryBuilder(model, opts...)
+	if err := db.checkLock(query, queryOpt); err != nil {
+		return nil, err
+	}
 	query = db.DefaultSort(model, query, queryOpt)
 
 	list := reflect.New(reflect.SliceOf(reflect.TypeOf(model).Elem()))
@@ -375,28 +460,39 @@ func (db *DB) FindAllWithModel(model interface{}, opts ...Option) (interface{},
 	return list.Elem().Interface(), nil
 }
 
-func (db *DB) FindPageWithModel(model interface{}, opts ...Option) (interface{}, int, error) {
+func (db *DB) FindPageWithModel(model interface{}, opts ...Option) (models interface{}, total int, err error) {
+	opts, end := db.observe("FindPageWithModel", OperationFind, model, opts)
+	defer end(&err)
+	list, info, err := db.FindPageInfoWithModel(model, opts...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, info.Total, nil
+}
+
+func (db *DB) FindPageInfoWithModel(model interface{}, opts ...Option) (models interface{}, info *PageInfo, err error) {
+	opts, end := db.observe("FindPageInfoWithModel", OperationFind, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
-		return nil, 0, ErrorModel()
+		return nil, nil, ErrorModel()
+	}
+	query, queryOpt := db.readQueryBuilder(model, opts...)
+	if err := db.checkLock(query, queryOpt); err != nil {
+		return nil, nil, err
 	}
-	query, queryOpt := db.QueryBuilder(model, opts...)
 	query = db.DefaultSort(model, query, queryOpt)
 
 	list := reflect.New(reflect.SliceOf(reflect.TypeOf(model).Elem()))
-	if err := query.Find(list.Interface()).Error; err != nil {
-		return nil, 0, ErrorQuery(err)
-	}
-
-	var total int64 = 0
-	if queryOpt.Pageable != nil {
-		if err := db.CountBuilder(query).Count(&total).Error; err != nil {
-			return nil, 0, err
-		}
+	info, err = db.page(list.Interface(), query, queryOpt)
+	if err != nil {
+		return nil, nil, err
 	}
-	return list.Elem().Interface(), int(total), nil
+	return list.Elem().Interface(), info, nil
 }
 
-func (db *DB) FindAll(list interface{}, opts ...Option) error {
+func (db *DB) FindAll(list interface{}, opts ...Option) (err error) {
+	opts, end := db.observe("FindAll", OperationFind, list, opts)
+	defer end(&err)
 	listT := reflect.TypeOf(list)
 	if listT.Kind() != reflect.Ptr || listT.Elem().Kind() != reflect.Slice {
 		return ErrorModel()
@@ -411,7 +507,10 @@ func (db *DB) FindAll(list interface{}, opts ...Option) error {
 	}
 
 	model := reflect.New(elem).Interface()
-	query, queryOpt := db.QueryBuilder(model, opts...)
+	query, queryOpt := db.readQueryBuilder(model, opts...)
+	if err := db.checkLock(query, queryOpt); err != nil {
+		return err
+	}
 	query = db.DefaultSort(model, query, queryOpt)
 
 	//TODO: cache
@@ -425,13 +524,25 @@ func (db *DB) FindAll(list interface{}, opts ...Option) error {
 	return nil
 }
 
-func (db *DB) FindPage(list interface{}, opts ...Option) (int, error) {
+func (db *DB) FindPage(list interface{}, opts ...Option) (total int, err error) {
+	opts, end := db.observe("FindPage", OperationFind, list, opts)
+	defer end(&err)
+	info, err := db.FindPageInfo(list, opts...)
+	if err != nil {
+		return 0, err
+	}
+	return info.Total, nil
+}
+
+func (db *DB) FindPageInfo(list interface{}, opts ...Option) (info *PageInfo, err error) {
+	opts, end := db.observe("FindPageInfo", OperationFind, list, opts)
+	defer end(&err)
 	listT := reflect.TypeOf(list)
 	if listT.Kind() != reflect.Ptr || listT.Elem().Kind() != reflect.Slice {
-		return 0, ErrorModel()
+		return nil, ErrorModel()
 	}
 	if !(listT.Elem().Elem().Kind() == reflect.Struct || (listT.Elem().Elem().Kind() == reflect.Ptr && listT.Elem().Elem().Elem().Kind() == reflect.Struct)) {
-		return 0, ErrorModel()
+		return nil, ErrorModel()
 	}
 
 	elem := listT.Elem().Elem()
@@ -440,26 +551,24 @@ func (db *DB) FindPage(list interface{}, opts ...Option) (int, error) {
 	}
 
 	model := reflect.New(elem).Interface()
-	query, queryOpt := db.QueryBuilder(model, opts...)
-	query = db.DefaultSort(model, query, queryOpt)
-
-	var total int64 = 0
-	if err := query.Find(list).Error; err != nil {
-		return 0, ErrorQuery(err)
-	}
-	if queryOpt.Pageable != nil {
-		if err := db.CountBuilder(query).Count(&total).Error; err != nil {
-			return 0, err
-		}
+	query, queryOpt := db.readQueryBuilder(model, opts...)
+	if err := db.checkLock(query, queryOpt); err != nil {
+		return nil, err
 	}
-	return int(total), nil
+	query = db.DefaultSort(model, query, queryOpt)
+	return db.page(list, query, queryOpt)
 }
 
-func (db *DB) FindPluck(model interface{}, opts ...Option) error {
+func (db *DB) FindPluck(model interface{}, opts ...Option) (err error) {
+	opts, end := db.observe("FindPluck", OperationFind, model, opts)
+	defer end(&err)
 	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
 		return ErrorModel()
 	}
-	query, queryOpt := db.QueryBuilder(model, opts...)
+	query, queryOpt := db.readQueryBuilder(model, opts...)
+	if err := db.checkLock(query, queryOpt); err != nil {
+		return err
+	}
 	if queryOpt.Pluck == nil {
 		return ErrorPluck()
 	}
@@ -0,0 +1,108 @@
+package mysql
+
+import "github.com/go-tron/types/pageable"
+
+type Page[T any] struct {
+	List       []T  `json:"list"`
+	Total      int  `json:"total"`
+	TotalExact bool `json:"totalExact"`
+	HasNext    bool `json:"hasNext"`
+}
+
+// Repository typed wrapper of BaseService
+type Repository[T any] struct {
+	Service *BaseService
+}
+
+func NewRepository[T any](db *DB) *Repository[T] {
+	return &Repository[T]{
+		Service: &BaseService{DB: db, Model: new(T)},
+	}
+}
+
+func (r *Repository[T]) WithDB(db *DB) *Repository[T] {
+	return &Repository[T]{Service: r.Service.WithDB(db)}
+}
+
+// FindById find the model by id, filters and the other query options are passed as opts, e.g. db.WithFilters
+func (r *Repository[T]) FindById(id interface{}, opts ...Option) (*T, error) {
+	model, err := r.Service.FindByIdWithOptions(id, opts)
+	if err != nil {
+		return nil, err
+	}
+	return model.(*T), nil
+}
+
+// FindOne find the single model matching opts, it returns the not found error of T when there is none
+func (r *Repository[T]) FindOne(opts ...Option) (*T, error) {
+	model := new(T)
+	if err := r.Service.DB.FindOne(model, opts...); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+func (r *Repository[T]) FindAll(opts ...Option) ([]T, error) {
+	list, err := r.Service.FindAllWithOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	return list.([]T), nil
+}
+
+func (r *Repository[T]) FindPage(pageable *pageable.Pageable, opts ...Option) (Page[T], error) {
+	res, err := r.Service.FindPageWithOptions(pageable, opts)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	return Page[T]{List: res.List.([]T), Total: res.Total, TotalExact: res.TotalExact, HasNext: res.HasNext}, nil
+}
+
+func (r *Repository[T]) Create(value *T, opts ...Option) (*T, error) {
+	if _, err := r.Service.CreateWithOptions(value, opts); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (r *Repository[T]) CreateWithUserId(value *T, userId int, opts ...Option) (*T, error) {
+	SetCreatedBy(value, userId)
+	return r.Create(value, opts...)
+}
+
+func (r *Repository[T]) Update(value *T, opts ...Option) (*T, error) {
+	if _, err := r.Service.UpdateWithOptions(value, opts); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (r *Repository[T]) UpdateWithUserId(value *T, userId int, opts ...Option) (*T, error) {
+	SetUpdatedBy(value, userId)
+	return r.Update(value, opts...)
+}
+
+func (r *Repository[T]) Upsert(value *T, opts ...Option) (*T, error) {
+	if _, err := r.Service.UpsertWithOptions(value, opts); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (r *Repository[T]) UpsertWithUserId(value *T, userId int, opts ...Option) (*T, error) {
+	SetCreatedBy(value, userId)
+	return r.Upsert(value, opts...)
+}
+
+func (r *Repository[T]) Remove(id interface{}, opts ...Option) error {
+	return r.Service.RemoveByIdWithOptions(id, opts)
+}
+
+func (r *Repository[T]) RemoveWithUserId(id interface{}, userId int, opts ...Option) error {
+	value, err := r.Service.NewModelWithId(id)
+	if err != nil {
+		return err
+	}
+	SetUpdatedBy(value, userId)
+	return r.Service.RemoveWithOptions(value, append([]Option{r.Service.DB.WithCurrentVersion()}, opts...))
+}
@@ -0,0 +1,12 @@
+package mysql
+
+import "testing"
+
+func TestRepositoryFindOneNotFound(t *testing.T) {
+	db := newDryRunDB(t)
+	repository := NewRepository[versionItem](db)
+	model, err := repository.FindOne(db.WithFilters(map[string]interface{}{"name": "a"}))
+	if model != nil || !IsRecordNotFoundError(err) {
+		t.Errorf("FindOne() = %v, %v, want nil and record not found", model, err)
+	}
+}
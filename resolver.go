@@ -0,0 +1,29 @@
+package mysql
+
+import (
+	"gorm.io/gorm"
+	"sync/atomic"
+)
+
+// replica returns the next read replica in round-robin order, or the primary when none is configured.
+func (db *DB) replica() *gorm.DB {
+	if len(db.replicas) == 0 {
+		return db.DB
+	}
+	i := atomic.AddUint32(&db.replicaIndex, 1)
+	return db.replicas[int(i)%len(db.replicas)]
+}
+
+func (db *DB) closeAll() error {
+	var result error
+	for _, d := range append([]*gorm.DB{db.DB}, db.replicas...) {
+		sqlDB, err := d.DB()
+		if err == nil {
+			err = sqlDB.Close()
+		}
+		if err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
@@ -21,8 +21,10 @@ type PageReq struct {
 }
 
 type PageRes struct {
-	List  interface{} `json:"list"`
-	Total int         `json:"total"`
+	List       interface{} `json:"list"`
+	Total      int         `json:"total"`
+	TotalExact bool        `json:"totalExact"`
+	HasNext    bool        `json:"hasNext"`
 }
 
 type TitleRes struct {
@@ -36,6 +38,13 @@ type BaseService struct {
 	Pk       string
 }
 
+// WithDB return a copy of the service using db, e.g. the tx of DB.TransactionDB
+func (b *BaseService) WithDB(db *DB) *BaseService {
+	service := *b
+	service.DB = db
+	return &service
+}
+
 func (b *BaseService) GetPk() (string, error) {
 	if b.Pk != "" {
 		return b.Pk, nil
@@ -86,7 +95,11 @@ func (b *BaseService) NewModelWithValue(value interface{}) (interface{}, error)
 }
 
 func (b *BaseService) Create(value interface{}) (interface{}, error) {
-	err := b.DB.Create(value)
+	return b.CreateWithOptions(value, nil)
+}
+
+func (b *BaseService) CreateWithOptions(value interface{}, opts []Option) (interface{}, error) {
+	err := b.DB.Create(value, opts...)
 	return value, err
 }
 
@@ -96,17 +109,26 @@ func (b *BaseService) CreateWithUserId(value interface{}, userId int) (interface
 }
 
 func (b *BaseService) Update(value interface{}, filters ...map[string]interface{}) (interface{}, error) {
+	return b.UpdateWithOptions(value, nil, filters...)
+}
+
+func (b *BaseService) UpdateWithOptions(value interface{}, opts []Option, filters ...map[string]interface{}) (interface{}, error) {
 	if err := b.DB.UpdateById(
 		value,
 		value,
-		b.DB.WithOmit("created_at", "created_by"),
-		b.DB.WithFilters(filters...),
+		append([]Option{
+			b.DB.WithOmit("created_at", "created_by"),
+			b.DB.WithFilters(filters...),
+		}, opts...)...,
 	); err != nil {
 		return nil, err
 	}
 
 	if err := b.DB.FindById(
 		value,
+		append([]Option{
+			b.DB.WithPrimary(),
+		}, opts...)...,
 	); err != nil {
 		return nil, err
 	}
@@ -127,22 +149,61 @@ func (b *BaseService) UpdateOrCreateWithUserId(value interface{}, userId int, fi
 	return r, err
 }
 
+func (b *BaseService) Upsert(value interface{}) (interface{}, error) {
+	return b.UpsertWithOptions(value, nil)
+}
+
+func (b *BaseService) UpsertWithOptions(value interface{}, opts []Option) (interface{}, error) {
+	queryOpt := &QueryOption{}
+	for _, apply := range opts {
+		if apply != nil {
+			apply(queryOpt)
+		}
+	}
+	if len(queryOpt.UpsertColumns) == 0 {
+		columns, err := b.DB.columnNames(value, "created_at", "created_by")
+		if err != nil {
+			return nil, err
+		}
+		opts = append([]Option{b.DB.WithUpsertColumns(columns...)}, opts...)
+	}
+	if err := b.DB.Upsert(value, opts...); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (b *BaseService) UpsertWithUserId(value interface{}, userId int) (interface{}, error) {
+	SetCreatedBy(value, userId)
+	return b.Upsert(value)
+}
+
 func (b *BaseService) Remove(value interface{}, filters ...map[string]interface{}) error {
+	return b.RemoveWithOptions(value, nil, filters...)
+}
+
+func (b *BaseService) RemoveWithOptions(value interface{}, opts []Option, filters ...map[string]interface{}) error {
 	SetDeleted(value)
 	return b.DB.UpdateById(
 		value,
 		value,
-		b.DB.WithAttend("updated_at", "updated_by", "deleted"),
-		b.DB.WithFilters(filters...),
+		append([]Option{
+			b.DB.WithAttend("updated_at", "updated_by", "deleted"),
+			b.DB.WithFilters(filters...),
+		}, opts...)...,
 	)
 }
 
 func (b *BaseService) RemoveById(id interface{}, filters ...map[string]interface{}) error {
+	return b.RemoveByIdWithOptions(id, nil, filters...)
+}
+
+func (b *BaseService) RemoveByIdWithOptions(id interface{}, opts []Option, filters ...map[string]interface{}) error {
 	value, err := b.NewModelWithId(id)
 	if err != nil {
 		return err
 	}
-	return b.Remove(value, filters...)
+	return b.RemoveWithOptions(value, append([]Option{b.DB.WithCurrentVersion()}, opts...), filters...)
 }
 
 func (b *BaseService) RemoveByIdWithUserId(id interface{}, userId int, filters ...map[string]interface{}) error {
@@ -151,7 +212,7 @@ func (b *BaseService) RemoveByIdWithUserId(id interface{}, userId int, filters .
 		return err
 	}
 	SetUpdatedBy(value, userId)
-	return b.Remove(value, filters...)
+	return b.RemoveWithOptions(value, []Option{b.DB.WithCurrentVersion()}, filters...)
 }
 
 func (b *BaseService) FindTitle(id interface{}, filters ...map[string]interface{}) (*TitleRes, error) {
@@ -163,13 +224,19 @@ func (b *BaseService) FindTitle(id interface{}, filters ...map[string]interface{
 }
 
 func (b *BaseService) FindById(id interface{}, filters ...map[string]interface{}) (interface{}, error) {
+	return b.FindByIdWithOptions(id, nil, filters...)
+}
+
+func (b *BaseService) FindByIdWithOptions(id interface{}, opts []Option, filters ...map[string]interface{}) (interface{}, error) {
 	model, err := b.NewModelWithId(id)
 	if err != nil {
 		return nil, err
 	}
 	if err := b.DB.FindById(
 		model,
-		b.DB.WithFilters(filters...),
+		append([]Option{
+			b.DB.WithFilters(filters...),
+		}, opts...)...,
 	); err != nil {
 		return nil, err
 	}
@@ -177,13 +244,19 @@ func (b *BaseService) FindById(id interface{}, filters ...map[string]interface{}
 }
 
 func (b *BaseService) FindAll(filters ...map[string]interface{}) (interface{}, error) {
+	return b.FindAllWithOptions(nil, filters...)
+}
+
+func (b *BaseService) FindAllWithOptions(opts []Option, filters ...map[string]interface{}) (interface{}, error) {
 	model, err := b.NewModel()
 	if err != nil {
 		return nil, err
 	}
 	list, err := b.DB.FindAllWithModel(
 		model,
-		b.DB.WithFilters(filters...),
+		append([]Option{
+			b.DB.WithFilters(filters...),
+		}, opts...)...,
 	)
 	if err != nil {
 		return nil, err
@@ -192,22 +265,32 @@ func (b *BaseService) FindAll(filters ...map[string]interface{}) (interface{}, e
 }
 
 func (b *BaseService) FindPage(pageable *pageable.Pageable, filters ...map[string]interface{}) (*PageRes, error) {
+	return b.FindPageWithOptions(pageable, nil, filters...)
+}
+
+func (b *BaseService) FindPageWithOptions(pageable *pageable.Pageable, opts []Option, filters ...map[string]interface{}) (*PageRes, error) {
 	model, err := b.NewModel()
 	if err != nil {
 		return nil, err
 	}
-	list, total, err := b.DB.FindPageWithModel(
+	list, info, err := b.DB.FindPageInfoWithModel(
 		model,
-		b.DB.WithFilters(filters...),
-		b.DB.WithPageable(pageable),
+		append([]Option{
+			b.DB.WithFilters(filters...),
+			b.DB.WithPageable(pageable),
+		}, opts...)...,
 	)
 	if err != nil {
 		return nil, err
 	}
-	return &PageRes{Total: total, List: list}, nil
+	return &PageRes{Total: info.Total, List: list, TotalExact: info.TotalExact, HasNext: info.HasNext}, nil
 }
 
 func (b *BaseService) FindOne(filters ...map[string]interface{}) (interface{}, error) {
+	return b.FindOneWithOptions(nil, filters...)
+}
+
+func (b *BaseService) FindOneWithOptions(opts []Option, filters ...map[string]interface{}) (interface{}, error) {
 	model, err := b.NewModel()
 	if err != nil {
 		return nil, err
@@ -215,8 +298,10 @@ func (b *BaseService) FindOne(filters ...map[string]interface{}) (interface{}, e
 
 	if err := b.DB.FindOne(
 		model,
-		b.DB.WithFilters(filters...),
-		b.DB.WithIgnoreNotFound(),
+		append([]Option{
+			b.DB.WithFilters(filters...),
+			b.DB.WithIgnoreNotFound(),
+		}, opts...)...,
 	); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,112 @@
+package mysql
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"sync"
+)
+
+type shutdown struct {
+	mu      sync.Mutex
+	closed  bool
+	running sync.WaitGroup
+	hooks   []func(ctx context.Context) error
+}
+
+func (s *shutdown) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *shutdown) begin() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrorClosed()
+	}
+	s.running.Add(1)
+	return nil
+}
+
+func (s *shutdown) done() {
+	s.running.Done()
+}
+
+func (s *shutdown) register(db *gorm.DB) error {
+	reject := func(tx *gorm.DB) {
+		if inTransaction(tx) {
+			return
+		}
+		if s.isClosed() {
+			tx.AddError(ErrorClosed())
+		}
+	}
+	callback := db.Callback()
+	if err := callback.Create().Before("*").Register("mysql:closed", reject); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("*").Register("mysql:closed", reject); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("*").Register("mysql:closed", reject); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("*").Register("mysql:closed", reject); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("*").Register("mysql:closed", reject); err != nil {
+		return err
+	}
+	return callback.Raw().Before("*").Register("mysql:closed", reject)
+}
+
+// OnShutdown register a hook which runs in Close after running transactions finished and before the pool is closed
+func (db *DB) OnShutdown(hook func(ctx context.Context) error) {
+	if db.shutdown == nil {
+		db.shutdown = &shutdown{}
+	}
+	db.shutdown.mu.Lock()
+	defer db.shutdown.mu.Unlock()
+	db.shutdown.hooks = append(db.shutdown.hooks, hook)
+}
+
+// Close stop accepting new work, wait for running transactions until ctx is done, run shutdown hooks then close the pools
+func (db *DB) Close(ctx context.Context) error {
+	if db.shutdown == nil {
+		if err := db.closeAll(); err != nil {
+			return ErrorQuery(err)
+		}
+		return nil
+	}
+	db.shutdown.mu.Lock()
+	if db.shutdown.closed {
+		db.shutdown.mu.Unlock()
+		return ErrorClosed()
+	}
+	db.shutdown.closed = true
+	hooks := db.shutdown.hooks
+	db.shutdown.mu.Unlock()
+
+	var result error
+	finished := make(chan struct{})
+	go func() {
+		db.shutdown.running.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		result = ErrorCloseTimeout(ctx.Err())
+	}
+
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil && result == nil {
+			result = err
+		}
+	}
+	if err := db.closeAll(); err != nil && result == nil {
+		result = ErrorQuery(err)
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCloseWaitsForTransactions(t *testing.T) {
+	db := newDryRunDB(t)
+	db.shutdown = &shutdown{}
+	if err := db.shutdown.register(db.DB); err != nil {
+		t.Fatal(err)
+	}
+	hooked := false
+	db.OnShutdown(func(ctx context.Context) error {
+		hooked = true
+		return nil
+	})
+	if err := db.shutdown.begin(); err != nil {
+		t.Fatal(err)
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- db.Close(context.Background())
+	}()
+	select {
+	case err := <-closed:
+		t.Fatalf("Close() = %v before the transaction finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := db.shutdown.begin(); ErrorCode(err) != "1137" {
+		t.Errorf("begin() = %v while closing, want closed", err)
+	}
+	if err := db.DB.Find(&[]versionItem{}).Error; ErrorCode(err) != "1137" {
+		t.Errorf("query = %v while closing, want closed", err)
+	}
+
+	db.shutdown.done()
+	if err := <-closed; err != nil || !hooked {
+		t.Errorf("Close() = %v, hooked = %v, want nil, true", err, hooked)
+	}
+	if err := db.Close(context.Background()); ErrorCode(err) != "1137" {
+		t.Errorf("second Close() = %v, want closed", err)
+	}
+}
+
+func TestCloseTimeout(t *testing.T) {
+	db := newDryRunDB(t)
+	hooked := false
+	db.OnShutdown(func(ctx context.Context) error {
+		hooked = true
+		return nil
+	})
+	if err := db.shutdown.begin(); err != nil {
+		t.Fatal(err)
+	}
+	defer db.shutdown.done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := db.Close(ctx); ErrorCode(err) != "1138" || !hooked {
+		t.Errorf("Close() = %v, hooked = %v, want close timeout, true", err, hooked)
+	}
+}
+
+func TestCloseWithoutShutdown(t *testing.T) {
+	db := newDryRunDB(t)
+	if err := db.Close(context.Background()); err != nil {
+		t.Errorf("Close() = %v, want nil", err)
+	}
+}
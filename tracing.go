@@ -0,0 +1,187 @@
+package mysql
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"reflect"
+)
+
+// Tracer mirrors the subset of the OpenTelemetry trace.Tracer used by this package, so an otel tracer can be plugged in with a thin adapter
+type Tracer interface {
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+const (
+	AttributeSystem       = "db.system"
+	AttributeTable        = "db.sql.table"
+	AttributeOperation    = "db.operation"
+	AttributeStatement    = "db.statement"
+	AttributeRowsAffected = "db.rows_affected"
+)
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, spanName string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+func (noopSpan) RecordError(err error)                      {}
+func (noopSpan) End()                                       {}
+
+var NoopTracer Tracer = noopTracer{}
+
+func (db *DB) tracer() Tracer {
+	if db.Config.Tracer != nil {
+		return db.Config.Tracer
+	}
+	return NoopTracer
+}
+
+const tracingSpanKey = "mysql:tracing_span"
+
+type tracingPlugin struct {
+	tracer Tracer
+}
+
+func (p *tracingPlugin) Name() string {
+	return "mysql:tracing"
+}
+
+func (p *tracingPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("*").Register("mysql:tracing_before", p.before(OperationCreate)); err != nil {
+		return err
+	}
+	if err := callback.Create().After("*").Register("mysql:tracing_after", p.after(OperationCreate)); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("*").Register("mysql:tracing_before", p.before(OperationFind)); err != nil {
+		return err
+	}
+	if err := callback.Query().After("*").Register("mysql:tracing_after", p.after(OperationFind)); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("*").Register("mysql:tracing_before", p.before(OperationUpdate)); err != nil {
+		return err
+	}
+	if err := callback.Update().After("*").Register("mysql:tracing_after", p.after(OperationUpdate)); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("*").Register("mysql:tracing_before", p.before(OperationDelete)); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("*").Register("mysql:tracing_after", p.after(OperationDelete)); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("*").Register("mysql:tracing_before", p.before(OperationFind)); err != nil {
+		return err
+	}
+	if err := callback.Row().After("*").Register("mysql:tracing_after", p.after(OperationFind)); err != nil {
+		return err
+	}
+	if err := callback.Raw().Before("*").Register("mysql:tracing_before", p.before(OperationRaw)); err != nil {
+		return err
+	}
+	return callback.Raw().After("*").Register("mysql:tracing_after", p.after(OperationRaw))
+}
+
+func (p *tracingPlugin) before(operation string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		if tx.DryRun {
+			return
+		}
+		ctx := tx.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx, span := p.tracer.Start(ctx, "mysql."+operation)
+		tx.Statement.Context = ctx
+		tx.InstanceSet(tracingSpanKey, span)
+	}
+}
+
+func (p *tracingPlugin) after(operation string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		v, ok := tx.InstanceGet(tracingSpanKey)
+		if !ok {
+			return
+		}
+		span := v.(Span)
+		span.SetAttribute(AttributeSystem, "mysql")
+		span.SetAttribute(AttributeTable, tx.Statement.Table)
+		span.SetAttribute(AttributeOperation, operation)
+		span.SetAttribute(AttributeStatement, tx.Statement.SQL.String())
+		span.SetAttribute(AttributeRowsAffected, tx.Statement.RowsAffected)
+		if tx.Error != nil {
+			span.RecordError(tx.Error)
+		}
+		span.End()
+	}
+}
+
+type observeKey struct{}
+
+// observe start the span of a DB method, the statement spans of the method are its children,
+// and count the error of the outermost method with the code of this package
+func (db *DB) observe(method string, operation string, model interface{}, opts []Option) ([]Option, func(err *error)) {
+	if db.Config.Tracer == nil && db.Config.Metrics == nil {
+		return opts, func(*error) {}
+	}
+	queryOpt := &QueryOption{}
+	for _, apply := range opts {
+		if apply != nil {
+			apply(queryOpt)
+		}
+	}
+	ctx := queryOpt.Context
+	if ctx == nil && queryOpt.DB != nil {
+		ctx = queryOpt.DB.Statement.Context
+	}
+	if ctx == nil {
+		ctx = db.DB.Statement.Context
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	table := queryOpt.Table
+	if table == "" {
+		table = db.tableOf(model)
+	}
+	outermost := ctx.Value(observeKey{}) == nil
+	ctx, span := db.tracer().Start(context.WithValue(ctx, observeKey{}, method), "mysql."+method)
+	span.SetAttribute(AttributeSystem, "mysql")
+	span.SetAttribute(AttributeTable, table)
+	span.SetAttribute(AttributeOperation, operation)
+	return append(opts[:len(opts):len(opts)], db.WithCtx(ctx)), func(err *error) {
+		if *err != nil {
+			span.RecordError(*err)
+			if outermost && db.Config.Metrics != nil {
+				db.Config.Metrics.IncError(table, operation, ErrorCode(*err))
+			}
+		}
+		span.End()
+	}
+}
+
+func (db *DB) tableOf(model interface{}) string {
+	modelT := reflect.TypeOf(model)
+	for modelT != nil && (modelT.Kind() == reflect.Ptr || modelT.Kind() == reflect.Slice) {
+		modelT = modelT.Elem()
+	}
+	if modelT == nil || modelT.Kind() != reflect.Struct {
+		return ""
+	}
+	if name := GetTableName(reflect.New(modelT).Interface()); name != "" {
+		return name
+	}
+	return db.Config.NamingStrategy.TableName(modelT.Name())
+}
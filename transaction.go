@@ -1,17 +1,337 @@
 package mysql
 
 import (
-	"errors"
+	"context"
+	"database/sql"
 	"fmt"
 	"gorm.io/gorm"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
 )
 
-func (db *DB) Transaction(f func(tx *gorm.DB) error) (err error) {
-	tx := db.DB.Begin()
+type Propagation int
+
+const (
+	// PropagationRequired join the current transaction, begin a new one when there is none, default
+	PropagationRequired Propagation = iota
+	// PropagationRequiresNew always begin a new transaction
+	PropagationRequiresNew
+	// PropagationNested run within a SAVEPOINT of the current transaction, begin a new one when there is none
+	PropagationNested
+	// PropagationMandatory join the current transaction, fail when there is none
+	PropagationMandatory
+)
+
+type TxOptions struct {
+	Context      context.Context
+	Propagation  Propagation
+	Retries      int
+	RetryBackoff time.Duration
+	Isolation    sql.IsolationLevel
+	ReadOnly     bool
+	RePanic      bool
+}
+
+type TxOption func(*TxOptions)
+
+func (db *DB) WithTxContext(val context.Context) TxOption {
+	return func(opts *TxOptions) {
+		opts.Context = val
+	}
+}
+func (db *DB) WithPropagation(val Propagation) TxOption {
+	return func(opts *TxOptions) {
+		opts.Propagation = val
+	}
+}
+
+// WithRetry rerun a new transaction up to retries times when it fails with a deadlock or lock wait timeout
+func (db *DB) WithRetry(retries int, backoff time.Duration) TxOption {
+	return func(opts *TxOptions) {
+		opts.Retries = retries
+		opts.RetryBackoff = backoff
+	}
+}
+
+func (db *DB) WithIsolation(val sql.IsolationLevel) TxOption {
+	return func(opts *TxOptions) {
+		opts.Isolation = val
+	}
+}
+
+// WithReadOnly override Config.TxReadOnly for the transaction
+func (db *DB) WithReadOnly(val bool) TxOption {
+	return func(opts *TxOptions) {
+		opts.ReadOnly = val
+	}
+}
+
+// WithRePanic panic again with the original value after rolling back instead of returning a PanicError
+func (db *DB) WithRePanic() TxOption {
+	return func(opts *TxOptions) {
+		opts.RePanic = true
+	}
+}
+
+// ParseIsolationLevel parse isolation level from read uncommitted, read committed, repeatable read or serializable
+func ParseIsolationLevel(level string) (sql.IsolationLevel, error) {
+	switch strings.ToLower(strings.NewReplacer("_", " ", "-", " ").Replace(level)) {
+	case "":
+		return sql.LevelDefault, nil
+	case "read uncommitted":
+		return sql.LevelReadUncommitted, nil
+	case "read committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	}
+	return sql.LevelDefault, ErrorIsolation(level)
+}
+
+type txContextKey struct{}
+
+type txState struct {
+	owner        *DB
+	tx           *gorm.DB
+	savepoint    int
+	mu           sync.Mutex
+	rollbackOnly bool
+	onCommit     []func()
+	onRollback   []func()
+}
+
+// OnCommit register f to run after the transaction of tx committed, it is dropped when the transaction or the savepoint registering it rolls back
+func OnCommit(tx *gorm.DB, f func()) error {
+	state := txStateFromContext(tx.Statement.Context)
+	if state == nil {
+		return ErrorTransactionRequired()
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.onCommit = append(state.onCommit, f)
+	return nil
+}
+
+// OnRollback register f to run after the transaction of tx or the savepoint registering it rolled back
+func OnRollback(tx *gorm.DB, f func()) error {
+	state := txStateFromContext(tx.Statement.Context)
+	if state == nil {
+		return ErrorTransactionRequired()
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.onRollback = append(state.onRollback, f)
+	return nil
+}
+
+func (db *DB) OnCommit(f func()) error {
+	return OnCommit(db.DB, f)
+}
+
+func (db *DB) OnRollback(f func()) error {
+	return OnRollback(db.DB, f)
+}
+
+func (s *txState) setRollbackOnly() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rollbackOnly = true
+}
+
+func (s *txState) isRollbackOnly() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rollbackOnly
+}
+
+func (s *txState) nextSavepoint() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.savepoint++
+	return fmt.Sprintf("sp%d", s.savepoint)
+}
+
+func (s *txState) hooks() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.onCommit), len(s.onRollback)
+}
+
+// rollbackHooks drop the hooks registered after the marks and return the rollback ones of them
+func (s *txState) rollbackHooks(commitMark int, rollbackMark int) []func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hooks := append([]func(){}, s.onRollback[rollbackMark:]...)
+	s.onCommit = s.onCommit[:commitMark]
+	s.onRollback = s.onRollback[:rollbackMark]
+	return hooks
+}
+
+func (db *DB) runHooks(hooks []func()) {
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				if e := recover(); e != nil && db.Config.Logger != nil {
+					db.Config.Logger.Error(fmt.Sprintf("transaction hook panic: %v", e))
+				}
+			}()
+			hook()
+		}()
+	}
+}
+
+// TxFromContext return the transaction carried by ctx, the context of a tx passed to Transaction carries it
+func TxFromContext(ctx context.Context) *gorm.DB {
+	if state := txStateFromContext(ctx); state != nil {
+		return state.tx
+	}
+	return nil
+}
+
+func txStateFromContext(ctx context.Context) *txState {
+	if ctx == nil {
+		return nil
+	}
+	state, _ := ctx.Value(txContextKey{}).(*txState)
+	return state
+}
+
+func (db *DB) currentTx(ctx context.Context) *txState {
+	if inTransaction(db.DB) {
+		if state := txStateFromContext(db.DB.Statement.Context); state != nil {
+			return state
+		}
+		return &txState{tx: db.DB}
+	}
+	return db.txStateFromContext(ctx)
+}
+
+// txStateFromContext return the transaction carried by ctx only when it is owned by the root of db
+func (db *DB) txStateFromContext(ctx context.Context) *txState {
+	if state := txStateFromContext(ctx); state != nil && state.owner == db.root() {
+		return state
+	}
+	return nil
+}
+
+// join run f within the current transaction and mark it rollback-only when f fails or panics
+func (db *DB) join(current *txState, f func(tx *gorm.DB) error) error {
 	defer func() {
 		if e := recover(); e != nil {
-			err = errors.New(fmt.Sprint(e))
-			tx.Rollback()
+			current.setRollbackOnly()
+			panic(e)
+		}
+	}()
+	if err := f(current.tx); err != nil {
+		current.setRollbackOnly()
+		return err
+	}
+	return nil
+}
+
+func (db *DB) Transaction(f func(tx *gorm.DB) error, opts ...TxOption) error {
+	isolation, _ := ParseIsolationLevel(db.Config.TxIsolation)
+	txOpts := &TxOptions{Isolation: isolation, ReadOnly: db.Config.TxReadOnly}
+	for _, apply := range opts {
+		if apply != nil {
+			apply(txOpts)
+		}
+	}
+	ctx := txOpts.Context
+	if ctx == nil {
+		ctx = db.DB.Statement.Context
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	current := db.currentTx(ctx)
+	switch txOpts.Propagation {
+	case PropagationMandatory:
+		if current == nil {
+			return ErrorTransactionRequired()
+		}
+		return db.join(current, f)
+	case PropagationRequired:
+		if current != nil {
+			return db.join(current, f)
+		}
+	case PropagationNested:
+		if current != nil {
+			return db.savepoint(current, f, txOpts)
+		}
+	}
+	return db.root().beginWithRetry(ctx, f, txOpts)
+}
+
+// TransactionWithRetry run Transaction with the retry policy of Config, retrying 3 times when TxRetries is unset
+func (db *DB) TransactionWithRetry(f func(tx *gorm.DB) error, opts ...TxOption) error {
+	retries := db.Config.TxRetries
+	if retries <= 0 {
+		retries = 3
+	}
+	return db.Transaction(f, append([]TxOption{db.WithRetry(retries, db.Config.TxRetryBackoff)}, opts...)...)
+}
+
+func (db *DB) beginWithRetry(ctx context.Context, f func(tx *gorm.DB) error, txOpts *TxOptions) error {
+	backoff := txOpts.RetryBackoff
+	if backoff <= 0 {
+		backoff = 10 * time.Millisecond
+	}
+	for attempt := 1; ; attempt++ {
+		err := db.begin(ctx, f, txOpts)
+		if err == nil || attempt > txOpts.Retries || !IsRetryableError(err) {
+			return err
+		}
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff)))
+		if db.Config.Logger != nil {
+			db.Config.Logger.Warn(fmt.Sprintf("transaction retry %d/%d in %v: %v", attempt, txOpts.Retries, delay, err))
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+func (db *DB) begin(ctx context.Context, f func(tx *gorm.DB) error, txOpts *TxOptions) (err error) {
+	if db.shutdown != nil {
+		if err := db.shutdown.begin(); err != nil {
+			return err
+		}
+		defer db.shutdown.done()
+	}
+
+	ctx, span := db.tracer().Start(ctx, "mysql.transaction")
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}()
+
+	state := &txState{owner: db}
+	var sqlOpts []*sql.TxOptions
+	if txOpts.Isolation != sql.LevelDefault || txOpts.ReadOnly {
+		sqlOpts = append(sqlOpts, &sql.TxOptions{Isolation: txOpts.Isolation, ReadOnly: txOpts.ReadOnly})
+	}
+	tx := db.DB.WithContext(context.WithValue(ctx, txContextKey{}, state)).Begin(sqlOpts...)
+	state.tx = tx
+	defer func() {
+		if e := recover(); e != nil {
+			panicErr := newPanicError(e)
+			db.logRollback(tx.Rollback().Error, panicErr)
+			db.runHooks(state.rollbackHooks(0, 0))
+			err = panicErr
+			if txOpts.RePanic {
+				panic(e)
+			}
 		}
 	}()
 
@@ -20,12 +340,84 @@ func (db *DB) Transaction(f func(tx *gorm.DB) error) (err error) {
 	}
 
 	if err := f(tx); err != nil {
-		tx.Rollback()
+		db.logRollback(tx.Rollback().Error, err)
+		db.runHooks(state.rollbackHooks(0, 0))
+		return err
+	}
+
+	if state.isRollbackOnly() {
+		err := ErrorRollbackOnly()
+		db.logRollback(tx.Rollback().Error, err)
+		db.runHooks(state.rollbackHooks(0, 0))
 		return err
 	}
 
 	if err := tx.Commit().Error; err != nil {
+		db.runHooks(state.rollbackHooks(0, 0))
 		return err
 	}
+	db.runHooks(state.onCommit)
 	return nil
 }
+
+func (db *DB) savepoint(current *txState, f func(tx *gorm.DB) error, txOpts *TxOptions) (err error) {
+	name := current.nextSavepoint()
+	tx := current.tx
+	if err := tx.SavePoint(name).Error; err != nil {
+		return err
+	}
+	commitMark, rollbackMark := current.hooks()
+	defer func() {
+		if e := recover(); e != nil {
+			panicErr := newPanicError(e)
+			db.logRollback(tx.RollbackTo(name).Error, panicErr)
+			db.runHooks(current.rollbackHooks(commitMark, rollbackMark))
+			err = panicErr
+			if txOpts.RePanic {
+				panic(e)
+			}
+		}
+	}()
+
+	if err := f(tx); err != nil {
+		db.logRollback(tx.RollbackTo(name).Error, err)
+		db.runHooks(current.rollbackHooks(commitMark, rollbackMark))
+		return err
+	}
+	return nil
+}
+
+func (db *DB) logRollback(rollbackErr error, err error) {
+	if rollbackErr != nil && db.Config.Logger != nil {
+		db.Config.Logger.Error(fmt.Sprintf("transaction rollback failed: %v, caused by: %v", rollbackErr, err))
+	}
+}
+
+// TransactionDB run f with a *DB bound to the transaction, so every method of it and of a BaseService using it runs within the transaction
+func (db *DB) TransactionDB(f func(tx *DB) error, opts ...TxOption) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return f(db.WithTx(tx))
+	}, opts...)
+}
+
+// WithTx return a *DB whose methods run on tx, a tx not begun by Transaction gets its own state for
+// savepoints and rollback-only marks, but its OnCommit and OnRollback hooks are never run
+func (db *DB) WithTx(tx *gorm.DB) *DB {
+	if inTransaction(tx) && txStateFromContext(tx.Statement.Context) == nil {
+		ctx := tx.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		state := &txState{owner: db.root()}
+		tx = tx.WithContext(context.WithValue(ctx, txContextKey{}, state))
+		state.tx = tx
+	}
+	return &DB{Config: db.Config, DB: tx, shutdown: db.shutdown, parent: db.root()}
+}
+
+func (db *DB) root() *DB {
+	if db.parent != nil {
+		return db.parent
+	}
+	return db
+}
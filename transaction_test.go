@@ -0,0 +1,293 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fakeConn is a connection pool recording the statements and the transactions run on it
+type fakeConn struct {
+	mu        sync.Mutex
+	execs     []string
+	commits   int
+	rollbacks int
+	query     func(ctx context.Context, query string) (*sql.Rows, error)
+}
+
+func (c *fakeConn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.execs = append(c.execs, query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if c.query != nil {
+		return c.query(ctx, query)
+	}
+	return nil, errors.New("query is not supported")
+}
+
+func (c *fakeConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func (c *fakeConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return &fakeTx{c}, nil
+}
+
+type fakeTx struct {
+	*fakeConn
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.commits++
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.rollbacks++
+	return nil
+}
+
+func newFakeDB(t *testing.T) (*DB, *fakeConn) {
+	conn := &fakeConn{}
+	namingStrategy := &schema.NamingStrategy{SingularTable: true}
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      conn,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		NamingStrategy:         namingStrategy,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &DB{Config: &Config{NamingStrategy: namingStrategy}, DB: db}, conn
+}
+
+func TestParseIsolationLevel(t *testing.T) {
+	tests := []struct {
+		level string
+		want  sql.IsolationLevel
+	}{
+		{"", sql.LevelDefault},
+		{"read uncommitted", sql.LevelReadUncommitted},
+		{"READ_COMMITTED", sql.LevelReadCommitted},
+		{"repeatable-read", sql.LevelRepeatableRead},
+		{"Serializable", sql.LevelSerializable},
+	}
+	for _, tt := range tests {
+		got, err := ParseIsolationLevel(tt.level)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseIsolationLevel(%q) = %v, %v, want %v", tt.level, got, err, tt.want)
+		}
+	}
+	if _, err := ParseIsolationLevel("snapshot"); err == nil {
+		t.Error("ParseIsolationLevel(\"snapshot\") error = nil")
+	}
+}
+
+func TestTransactionRequiredJoins(t *testing.T) {
+	db, conn := newFakeDB(t)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return db.Transaction(func(inner *gorm.DB) error {
+			if inner.Statement.ConnPool != tx.Statement.ConnPool {
+				t.Error("required transaction did not join the current one")
+			}
+			return nil
+		}, db.WithTxContext(tx.Statement.Context))
+	})
+	if err != nil || conn.commits != 1 || conn.rollbacks != 0 {
+		t.Errorf("err = %v, commits = %d, rollbacks = %d, want nil, 1, 0", err, conn.commits, conn.rollbacks)
+	}
+}
+
+func TestTransactionMandatory(t *testing.T) {
+	db, _ := newFakeDB(t)
+	called := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		called = true
+		return nil
+	}, db.WithPropagation(PropagationMandatory))
+	if ErrorCode(err) != "1119" || called {
+		t.Errorf("err = %v, called = %v, want transaction required", err, called)
+	}
+}
+
+func TestTransactionFailedJoinRollsBack(t *testing.T) {
+	db, conn := newFakeDB(t)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := db.WithTx(tx).Transaction(func(*gorm.DB) error {
+			return errors.New("boom")
+		}); err == nil {
+			t.Error("joined transaction error = nil")
+		}
+		return nil
+	})
+	if ErrorCode(err) != "1141" || conn.commits != 0 || conn.rollbacks != 1 {
+		t.Errorf("err = %v, commits = %d, rollbacks = %d, want rollback-only", err, conn.commits, conn.rollbacks)
+	}
+}
+
+func TestTransactionPanickedJoinRollsBack(t *testing.T) {
+	db, conn := newFakeDB(t)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		func() {
+			defer func() {
+				if e := recover(); e != "boom" {
+					t.Errorf("recover() = %v, want boom", e)
+				}
+			}()
+			_ = db.WithTx(tx).Transaction(func(*gorm.DB) error {
+				panic("boom")
+			})
+		}()
+		return nil
+	})
+	if ErrorCode(err) != "1141" || conn.commits != 0 || conn.rollbacks != 1 {
+		t.Errorf("err = %v, commits = %d, rollbacks = %d, want rollback-only", err, conn.commits, conn.rollbacks)
+	}
+}
+
+func TestTransactionIgnoresForeignContext(t *testing.T) {
+	db, conn := newFakeDB(t)
+	other, otherConn := newFakeDB(t)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return other.Transaction(func(inner *gorm.DB) error {
+			if inner.Statement.ConnPool == tx.Statement.ConnPool {
+				t.Error("joined a transaction of another db")
+			}
+			return nil
+		}, other.WithTxContext(tx.Statement.Context))
+	})
+	if err != nil || conn.commits != 1 || otherConn.commits != 1 {
+		t.Errorf("err = %v, commits = %d, %d, want nil, 1, 1", err, conn.commits, otherConn.commits)
+	}
+}
+
+func TestWithTxKeepsState(t *testing.T) {
+	db, conn := newFakeDB(t)
+	tx := db.DB.Begin()
+	txDB := db.WithTx(tx)
+	nested := txDB.WithPropagation(PropagationNested)
+	if err := txDB.Transaction(func(*gorm.DB) error { return nil }, nested); err != nil {
+		t.Fatal(err)
+	}
+	if err := txDB.Transaction(func(*gorm.DB) error { return errors.New("boom") }, nested); err == nil {
+		t.Error("nested transaction error = nil")
+	}
+	want := []string{"SAVEPOINT sp1", "SAVEPOINT sp2", "ROLLBACK TO SAVEPOINT sp2"}
+	if !reflect.DeepEqual(conn.execs, want) {
+		t.Errorf("execs = %q, want %q", conn.execs, want)
+	}
+
+	state := txStateFromContext(txDB.DB.Statement.Context)
+	if state == nil || state.isRollbackOnly() {
+		t.Fatal("WithTx did not attach a clean state")
+	}
+	_ = txDB.Transaction(func(*gorm.DB) error { return errors.New("boom") })
+	if !state.isRollbackOnly() {
+		t.Error("failed join did not mark the state rollback-only")
+	}
+}
+
+func TestTransactionHooks(t *testing.T) {
+	db, _ := newFakeDB(t)
+	var calls []string
+	hook := func(name string) func() {
+		return func() { calls = append(calls, name) }
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		_ = OnCommit(tx, hook("commit 1"))
+		_ = OnRollback(tx, hook("rollback 1"))
+		_ = OnCommit(tx, func() { panic("boom") })
+		_ = OnCommit(tx, hook("commit 2"))
+		if len(calls) != 0 {
+			t.Error("hooks ran before the commit")
+		}
+		return nil
+	})
+	if want := []string{"commit 1", "commit 2"}; err != nil || !reflect.DeepEqual(calls, want) {
+		t.Errorf("err = %v, calls = %q, want nil, %q", err, calls, want)
+	}
+
+	calls = nil
+	err = db.Transaction(func(tx *gorm.DB) error {
+		_ = OnCommit(tx, hook("commit"))
+		_ = OnRollback(tx, hook("rollback 1"))
+		_ = OnRollback(tx, hook("rollback 2"))
+		return errors.New("boom")
+	})
+	if want := []string{"rollback 1", "rollback 2"}; err == nil || !reflect.DeepEqual(calls, want) {
+		t.Errorf("err = %v, calls = %q, want boom, %q", err, calls, want)
+	}
+}
+
+func TestSavepointHooks(t *testing.T) {
+	db, _ := newFakeDB(t)
+	var calls []string
+	hook := func(name string) func() {
+		return func() { calls = append(calls, name) }
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		_ = OnCommit(tx, hook("outer commit"))
+		_ = OnRollback(tx, hook("outer rollback"))
+		_ = db.WithTx(tx).Transaction(func(inner *gorm.DB) error {
+			_ = OnCommit(inner, hook("inner commit"))
+			_ = OnRollback(inner, hook("inner rollback"))
+			return errors.New("boom")
+		}, db.WithPropagation(PropagationNested))
+		if want := []string{"inner rollback"}; !reflect.DeepEqual(calls, want) {
+			t.Errorf("calls after the savepoint rollback = %q, want %q", calls, want)
+		}
+		return nil
+	})
+	if want := []string{"inner rollback", "outer commit"}; err != nil || !reflect.DeepEqual(calls, want) {
+		t.Errorf("err = %v, calls = %q, want nil, %q", err, calls, want)
+	}
+}
+
+func TestRollbackHooks(t *testing.T) {
+	var calls []string
+	hook := func(name string) func() {
+		return func() { calls = append(calls, name) }
+	}
+	state := &txState{
+		onCommit:   []func(){hook("commit 1"), hook("commit 2")},
+		onRollback: []func(){hook("rollback 1"), hook("rollback 2"), hook("rollback 3")},
+	}
+	for _, f := range state.rollbackHooks(1, 1) {
+		f()
+	}
+	if want := []string{"rollback 2", "rollback 3"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("returned hooks = %q, want %q", calls, want)
+	}
+	if commits, rollbacks := state.hooks(); commits != 1 || rollbacks != 1 {
+		t.Errorf("hooks() = %d, %d, want 1, 1", commits, rollbacks)
+	}
+}
+
+func TestOnCommitRequiresTransaction(t *testing.T) {
+	db, _ := newFakeDB(t)
+	if err := db.OnCommit(func() {}); ErrorCode(err) != "1119" {
+		t.Errorf("OnCommit() = %v, want transaction required", err)
+	}
+}
@@ -0,0 +1,65 @@
+package mysql
+
+import (
+	"github.com/thoas/go-funk"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+)
+
+func (db *DB) upsertClause(queryOpt *QueryOption) clause.OnConflict {
+	onConflict := clause.OnConflict{}
+	var columns []string
+	for _, column := range queryOpt.UpsertColumns {
+		//an expression of the column overrides VALUES(column)
+		if _, ok := queryOpt.UpsertExprs[column]; !ok {
+			columns = append(columns, column)
+		}
+	}
+	if len(columns) > 0 {
+		onConflict.DoUpdates = clause.AssignmentColumns(columns)
+	}
+	for column, value := range queryOpt.UpsertExprs {
+		if expr, ok := value.(string); ok {
+			value = gorm.Expr(expr)
+		}
+		onConflict.DoUpdates = append(onConflict.DoUpdates, clause.Assignment{Column: clause.Column{Name: column}, Value: value})
+	}
+	if len(onConflict.DoUpdates) == 0 {
+		onConflict.UpdateAll = true
+	}
+	return onConflict
+}
+
+// Upsert insert model or update the conflicted row with INSERT ... ON DUPLICATE KEY UPDATE,
+// all columns are updated unless WithUpsertColumns or WithUpsertExpressions is supplied
+func (db *DB) Upsert(model interface{}, opts ...Option) (err error) {
+	opts, end := db.observe("Upsert", OperationCreate, model, opts)
+	defer end(&err)
+	if reflect.TypeOf(model).Kind() != reflect.Ptr || reflect.TypeOf(model).Elem().Kind() != reflect.Struct {
+		return ErrorModel()
+	}
+	query, queryOpt := db.QueryBuilder(model, opts...)
+	if err := query.Clauses(db.upsertClause(queryOpt)).Create(model).Error; err != nil {
+		if db.IsUniqueIndexError(err) {
+			return GetUniqueIndexError(model, err.Error())
+		}
+		return ErrorQuery(err)
+	}
+	return nil
+}
+
+func (db *DB) columnNames(model interface{}, omit ...string) ([]string, error) {
+	stmt := &gorm.Statement{DB: db.DB}
+	if err := stmt.Parse(model); err != nil {
+		return nil, ErrorModel()
+	}
+	var columns []string
+	for _, name := range stmt.Schema.DBNames {
+		if funk.ContainsString(stmt.Schema.PrimaryFieldDBNames, name) || funk.ContainsString(omit, name) {
+			continue
+		}
+		columns = append(columns, name)
+	}
+	return columns, nil
+}
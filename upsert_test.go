@@ -0,0 +1,32 @@
+package mysql
+
+import (
+	"gorm.io/gorm/clause"
+	"testing"
+)
+
+func TestUpsertClauseExpressionOverridesColumn(t *testing.T) {
+	db := &DB{}
+	onConflict := db.upsertClause(&QueryOption{
+		UpsertColumns: []string{"name", "count"},
+		UpsertExprs:   map[string]interface{}{"count": "count + 1"},
+	})
+	assigned := map[string]int{}
+	for _, assignment := range onConflict.DoUpdates {
+		assigned[assignment.Column.Name]++
+	}
+	if assigned["name"] != 1 || assigned["count"] != 1 || len(assigned) != 2 {
+		t.Errorf("assignments = %v, want name and count once each", assigned)
+	}
+	for _, assignment := range onConflict.DoUpdates {
+		if _, ok := assignment.Value.(clause.Expr); assignment.Column.Name == "count" && !ok {
+			t.Errorf("count = %#v, want the expression", assignment.Value)
+		}
+	}
+}
+
+func TestUpsertClauseUpdateAll(t *testing.T) {
+	if onConflict := (&DB{}).upsertClause(&QueryOption{}); !onConflict.UpdateAll {
+		t.Error("UpdateAll = false without upsert columns or expressions")
+	}
+}
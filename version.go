@@ -0,0 +1,96 @@
+package mysql
+
+import (
+	"gorm.io/gorm"
+	"reflect"
+	"strings"
+)
+
+// GetVersionField return the optimistic lock field, tagged with gorm:"version" or an integer named Version
+func GetVersionField(model interface{}) (reflect.StructField, bool) {
+	modelT := reflect.TypeOf(model)
+	if modelT.Kind() == reflect.Ptr {
+		modelT = modelT.Elem()
+	}
+	for i := 0; i < modelT.NumField(); i++ {
+		for _, tag := range strings.Split(modelT.Field(i).Tag.Get("gorm"), ";") {
+			if strings.EqualFold(strings.TrimSpace(tag), "version") {
+				return modelT.Field(i), true
+			}
+		}
+	}
+	field, ok := modelT.FieldByName("Version")
+	if !ok {
+		return field, false
+	}
+	switch field.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return field, true
+	}
+	return field, false
+}
+
+func getVersion(model interface{}, field reflect.StructField) reflect.Value {
+	modelV := reflect.ValueOf(model)
+	if modelV.Kind() == reflect.Ptr {
+		modelV = modelV.Elem()
+	}
+	return modelV.FieldByIndex(field.Index)
+}
+
+// versionLock replace the version of updates with version + 1 and add where version = expected unless it is nil, zero included
+func (db *DB) versionLock(model interface{}, values interface{}, expected interface{}, query *gorm.DB, queryOpt *QueryOption) (interface{}, *gorm.DB) {
+	field, ok := GetVersionField(model)
+	if !ok {
+		return values, query
+	}
+	m, ok := values.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		return values, query
+	}
+	column := db.getColumnName(field)
+	updates := map[string]interface{}{}
+	for k, v := range m {
+		if k == field.Name || k == column {
+			continue
+		}
+		updates[k] = v
+	}
+	if len(updates) == 0 {
+		return updates, query
+	}
+	updates[column] = gorm.Expr(column + " + 1")
+	if len(queryOpt.Attend) > 0 {
+		queryOpt.Attend = append(queryOpt.Attend, column)
+	}
+
+	queryOpt.versioned = true
+	if expected != nil {
+		query = query.Where(column+" = ?", expected)
+		queryOpt.version = expected
+	}
+	return updates, query
+}
+
+// bumpVersion set the version of model to the updated value after a versioned update
+func (db *DB) bumpVersion(model interface{}, queryOpt *QueryOption) {
+	field, ok := GetVersionField(model)
+	if !ok {
+		return
+	}
+	fieldV := getVersion(model, field)
+	expectedV := fieldV
+	if queryOpt.version != nil {
+		expectedV = reflect.ValueOf(queryOpt.version)
+	}
+	if !fieldV.CanSet() || !expectedV.CanConvert(fieldV.Type()) {
+		return
+	}
+	switch fieldV.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fieldV.SetInt(expectedV.Convert(fieldV.Type()).Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fieldV.SetUint(expectedV.Convert(fieldV.Type()).Uint() + 1)
+	}
+}
@@ -0,0 +1,150 @@
+package mysql
+
+import (
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"testing"
+)
+
+type versionItem struct {
+	Id      int `gorm:"primary_key"`
+	Name    string
+	Version int
+}
+
+func newDryRunDB(t *testing.T) *DB {
+	namingStrategy := &schema.NamingStrategy{SingularTable: true}
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "root@tcp(127.0.0.1:3306)/test",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		NamingStrategy:         namingStrategy,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &DB{Config: &Config{NamingStrategy: namingStrategy}, DB: db}
+}
+
+func TestVersionLockGuardsZeroVersion(t *testing.T) {
+	db := newDryRunDB(t)
+	item := &versionItem{Id: 1}
+	query, queryOpt := db.QueryBuilder(item)
+	updates, query := db.versionLock(item, map[string]interface{}{"name": "a", "version": 9}, 0, query, queryOpt)
+
+	stmt := query.Updates(updates).Statement
+	if want := "UPDATE `version_item` SET `name`=?,`version`=version + 1 WHERE version = ? AND `id` = ?"; stmt.SQL.String() != want {
+		t.Errorf("sql = %q, want %q", stmt.SQL.String(), want)
+	}
+	if want := []interface{}{"a", 0, 1}; !reflect.DeepEqual(stmt.Vars, want) {
+		t.Errorf("vars = %v, want %v", stmt.Vars, want)
+	}
+	if !queryOpt.versioned || queryOpt.version != 0 {
+		t.Errorf("versioned = %v, version = %v, want true, 0", queryOpt.versioned, queryOpt.version)
+	}
+}
+
+func TestVersionLockWithoutVersionField(t *testing.T) {
+	db := newDryRunDB(t)
+	type item struct {
+		Id   int `gorm:"primary_key"`
+		Name string
+	}
+	values := map[string]interface{}{"name": "a"}
+	query, queryOpt := db.QueryBuilder(&item{})
+	updates, _ := db.versionLock(&item{}, values, nil, query, queryOpt)
+	if !reflect.DeepEqual(updates, values) || queryOpt.versioned {
+		t.Errorf("updates = %v, versioned = %v, want unchanged", updates, queryOpt.versioned)
+	}
+}
+
+func TestUpdateByIdStaleRecord(t *testing.T) {
+	db := newDryRunDB(t)
+	err := db.UpdateById(&versionItem{Id: 1}, map[string]interface{}{"name": "a"})
+	if !IsStaleRecordError(err) {
+		t.Errorf("err = %v, want stale record", err)
+	}
+}
+
+func TestBumpVersion(t *testing.T) {
+	db := newDryRunDB(t)
+	item := &versionItem{Id: 1, Version: 3}
+	db.bumpVersion(item, &QueryOption{versioned: true, version: 3})
+	if item.Version != 4 {
+		t.Errorf("version = %d, want 4", item.Version)
+	}
+	db.bumpVersion(item, &QueryOption{versioned: true})
+	if item.Version != 5 {
+		t.Errorf("version = %d, want 5", item.Version)
+	}
+}
+
+type removableItem struct {
+	Id      int `gorm:"primary_key"`
+	Name    string
+	Deleted int64
+	Version int
+}
+
+func TestRemoveByIdGuardsCurrentVersion(t *testing.T) {
+	db := newDryRunDB(t)
+	if err := db.Callback().Query().After("gorm:query").Register("test:load", func(tx *gorm.DB) {
+		tx.Statement.ReflectValue.FieldByName("Version").SetInt(3)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var sql string
+	var vars []interface{}
+	if err := db.Callback().Update().After("gorm:update").Register("test:capture", func(tx *gorm.DB) {
+		sql, vars = tx.Statement.SQL.String(), tx.Statement.Vars
+		tx.RowsAffected = 1
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	service := &BaseService{DB: db, Model: &removableItem{}}
+	if err := service.RemoveById(1); err != nil {
+		t.Fatal(err)
+	}
+	if want := "UPDATE `removable_item` SET `deleted`=?,`version`=version + 1 WHERE removable_item.deleted = 0 AND version = ? AND `id` = ?"; sql != want {
+		t.Errorf("sql = %q, want %q", sql, want)
+	}
+	if len(vars) != 3 || vars[1] != 3 {
+		t.Errorf("vars = %v, want the loaded version 3", vars)
+	}
+}
+
+func TestGetVersionField(t *testing.T) {
+	type tagged struct {
+		Id  int
+		Rev int `gorm:"column:rev;VERSION"`
+	}
+	type named struct {
+		Id      int
+		Version uint
+	}
+	type schemaVersion struct {
+		Id      int
+		Version string
+	}
+	tests := []struct {
+		model interface{}
+		name  string
+		ok    bool
+	}{
+		{&tagged{}, "Rev", true},
+		{&named{}, "Version", true},
+		{&schemaVersion{}, "", false},
+	}
+	for _, tt := range tests {
+		field, ok := GetVersionField(tt.model)
+		if ok != tt.ok || (ok && field.Name != tt.name) {
+			t.Errorf("GetVersionField(%T) = %s, %v, want %s, %v", tt.model, field.Name, ok, tt.name, tt.ok)
+		}
+	}
+}